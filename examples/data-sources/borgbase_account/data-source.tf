@@ -0,0 +1,8 @@
+data "borgbase_account" "example" {}
+
+check "borgbase_repo_limit" {
+  assert {
+    condition     = data.borgbase_account.example.repo_count < data.borgbase_account.example.repo_limit
+    error_message = "The BorgBase plan does not allow any more repositories."
+  }
+}
@@ -0,0 +1,5 @@
+data "borgbase_borg_client_env" "example" {
+  repo_name       = "example"
+  ssh_key_file    = "/root/.ssh/borgbase"
+  host_public_key = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIMS3185JdDy7ffnr0nLWqVy8FaAQeVh1QYUSiNpW5ESq"
+}
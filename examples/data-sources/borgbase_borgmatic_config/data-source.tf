@@ -0,0 +1,12 @@
+data "borgbase_borgmatic_config" "example" {
+  repo_name              = "example"
+  source_directories     = ["/home", "/etc"]
+  encryption_passcommand = "cat /etc/borgmatic/passphrase"
+  ssh_key_file           = "/root/.ssh/borgbase"
+
+  retention = {
+    keep_daily   = 7
+    keep_weekly  = 4
+    keep_monthly = 6
+  }
+}
@@ -1,3 +1,16 @@
 provider "borgbase" {
   api_token = "changeme"
+
+  repo_defaults {
+    alert_days = 7
+    region     = "eu"
+
+    compaction = {
+      enabled       = true
+      hour          = 3
+      hour_timezone = "Europe/Berlin"
+      interval      = 1
+      interval_unit = "weeks"
+    }
+  }
 }
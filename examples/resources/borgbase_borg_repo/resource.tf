@@ -35,3 +35,11 @@ resource "borgbase_borg_repo" "repo_full" {
   rsync_keys       = [data.borgbase_ssh_key.rsync.id]
   sftp_enabled     = true
 }
+
+resource "borgbase_borg_repo" "repo_key_names" {
+  append_only           = true
+  append_only_key_names = ["append_only"]
+  full_access_key_names = ["full_access"]
+  name                  = "repo_key_names"
+  region                = "eu"
+}
@@ -0,0 +1,14 @@
+resource "borgbase_generated_ssh_key" "example" {
+  name    = "example"
+  comment = "backup@hostname"
+
+  rotation = {
+    rotate_after = "90d"
+  }
+}
+
+resource "local_sensitive_file" "example" {
+  content         = borgbase_generated_ssh_key.example.private_key_openssh
+  filename        = "${path.module}/id_ed25519"
+  file_permission = "0600"
+}
@@ -0,0 +1,2 @@
+# Repository key access can be imported using the repository and key IDs.
+terraform import borgbase_repo_key_access.web "repo_id/key_id"
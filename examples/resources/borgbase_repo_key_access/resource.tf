@@ -0,0 +1,13 @@
+data "borgbase_borg_repo" "shared" {
+  name = "shared"
+}
+
+data "borgbase_ssh_key" "web" {
+  name = "web"
+}
+
+resource "borgbase_repo_key_access" "web" {
+  repo_id = data.borgbase_borg_repo.shared.id
+  key_id  = data.borgbase_ssh_key.web.id
+  access  = "append_only"
+}
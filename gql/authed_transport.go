@@ -5,15 +5,25 @@ import (
 )
 
 type AuthedTransport struct {
-	apiKey  string
-	wrapped http.RoundTripper
+	apiKey    string
+	userAgent string
+	wrapped   http.RoundTripper
 }
 
-func NewAuthedTransport(apiKey string) *AuthedTransport {
-	return &AuthedTransport{apiKey: apiKey, wrapped: http.DefaultTransport}
+func NewAuthedTransport(apiKey, userAgent string) *AuthedTransport {
+	return &AuthedTransport{
+		apiKey:    apiKey,
+		userAgent: userAgent,
+		wrapped:   http.DefaultTransport,
+	}
 }
 
 func (t *AuthedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	req.Header.Set("Authorization", "bearer "+t.apiKey)
+	if t.apiKey != "" {
+		req.Header.Set("Authorization", "bearer "+t.apiKey)
+	}
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
 	return t.wrapped.RoundTrip(req)
 }
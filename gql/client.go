@@ -1,44 +1,71 @@
 package gql
 
-import "net/http"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// requestTimeout bounds requests whose context has no deadline, so that a
+// hung connection can't block callers which don't set their own. Callers
+// with a deadline, such as resources with configured timeouts, are bound by
+// it alone.
+const requestTimeout = time.Minute
 
 type Client struct {
 	client *http.Client
 	url    string
 }
 
-func (c *Client) Query(name string, schema interface{}, args Arguments) error {
-	return Execute(
-		c.client,
-		c.url,
-		QUERY,
-		name,
-		schema,
-		args,
-	)
+func (c *Client) Query(
+	ctx context.Context,
+	name string,
+	schema interface{},
+	args Arguments,
+) error {
+	return c.execute(ctx, QUERY, name, schema, args)
 }
 
 func (c *Client) Mutation(
+	ctx context.Context,
 	name string,
 	schema interface{},
 	args Arguments,
 ) error {
-	return Execute(
-		c.client,
-		c.url,
-		MUTATION,
-		name,
-		schema,
-		args,
-	)
+	return c.execute(ctx, MUTATION, name, schema, args)
 }
 
-func NewClient(url, apiKey string) *Client {
-	c := Client{url: url}
-	if apiKey != "" {
-		c.client = &http.Client{Transport: NewAuthedTransport(apiKey)}
-	} else {
-		c.client = http.DefaultClient
+func (c *Client) execute(
+	ctx context.Context,
+	operation OperationType,
+	name string,
+	schema interface{},
+	args Arguments,
+) error {
+	if _, ok := ctx.Deadline(); ok {
+		return Execute(ctx, c.client, c.url, operation, name, schema, args)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	err := Execute(ctx, c.client, c.url, operation, name, schema, args)
+	// The caller didn't set a deadline, so don't report the default one as
+	// its deadline being exceeded.
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%s %s: no response after %s", operation, name, requestTimeout)
+	}
+	return err
+}
+
+func NewClient(url, apiKey, userAgent string) *Client {
+	c := Client{
+		client: &http.Client{},
+		url:    url,
+	}
+	if apiKey != "" || userAgent != "" {
+		c.client.Transport = NewAuthedTransport(apiKey, userAgent)
 	}
 
 	return &c
@@ -2,6 +2,7 @@ package gql
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -191,18 +192,34 @@ func generateQuery(operation OperationType,
 			fmt.Sprintf("$%s: %s", name, t))
 		arguments = append(arguments, fmt.Sprintf("%s: $%s", name, name))
 	}
-	fields := generateFields(schema)
+	// Scalar results (e.g. a list of strings) have no selection set.
+	selection := ""
+	if unwrapNestedType(reflect.TypeOf(schema)).Kind() == reflect.Struct {
+		selection = fmt.Sprintf(" { %s }", generateFields(schema))
+	}
+
+	// GraphQL does not allow empty argument lists, so omit the parentheses
+	// entirely for operations without arguments.
+	if len(args) == 0 {
+		return fmt.Sprintf("%s %s { %s%s }",
+			operation,
+			name,
+			name,
+			selection), nil
+	}
 
-	return fmt.Sprintf("%s %s(%s) { %s(%s) { %s } }",
+	return fmt.Sprintf("%s %s(%s) { %s(%s)%s }",
 		operation,
 		name,
 		strings.Join(variables, ", "),
 		name,
 		strings.Join(arguments, ", "),
-		fields), nil
+		selection), nil
 }
 
-func Execute(client *http.Client,
+func Execute(
+	ctx context.Context,
+	client *http.Client,
 	url string,
 	operation OperationType,
 	name string,
@@ -230,7 +247,12 @@ func Execute(client *http.Client,
 		return fmt.Errorf("failed to marshal graphql query: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		url,
+		bytes.NewReader(data),
+	)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gjabell/terraform-provider-borgbase/gql"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &AccountDataSource{}
+
+func NewAccountDataSource() datasource.DataSource {
+	return &AccountDataSource{}
+}
+
+type AccountDataSource struct {
+	client *gql.Client
+}
+
+func (d *AccountDataSource) Metadata(
+	ctx context.Context,
+	req datasource.MetadataRequest,
+	resp *datasource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_account"
+}
+
+func (d *AccountDataSource) Schema(
+	ctx context.Context,
+	req datasource.SchemaRequest,
+	resp *datasource.SchemaResponse,
+) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "BorgBase account the provider is authenticated as.",
+		Attributes: map[string]schema.Attribute{
+			"email": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Email address of the account.",
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Internal BorgBase account identifier.",
+			},
+			"name": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Name of the account holder.",
+			},
+			"plan": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Name of the account's subscription plan.",
+			},
+			"repo_count": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Number of repositories in the account.",
+			},
+			"repo_limit": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Max number of repositories allowed by the plan.",
+			},
+			"storage_limit": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Max storage allowed by the plan in megabytes.",
+			},
+			"total_quota": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Sum of the enabled quotas of all repositories in megabytes.",
+			},
+			"total_usage": schema.Float64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Sum of the current usage of all repositories in megabytes.",
+			},
+		},
+	}
+}
+
+func (d *AccountDataSource) Configure(
+	ctx context.Context,
+	req datasource.ConfigureRequest,
+	resp *datasource.ConfigureResponse,
+) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*gql.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *gql.Client, got: %T. "+
+				"Please report this issue to the provider developers.",
+				req.ProviderData),
+		)
+		return
+	}
+	d.client = client
+}
+
+func (d *AccountDataSource) Read(
+	ctx context.Context,
+	req datasource.ReadRequest,
+	resp *datasource.ReadResponse,
+) {
+	var data AccountModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var payload AccountPayload
+	if err := d.client.Query(ctx, "me", &payload, gql.Arguments{}); err != nil {
+		resp.Diagnostics.AddError("Failed to read account", err.Error())
+		return
+	}
+
+	var repos BorgReposPayload
+	if err := d.client.Query(ctx, "repoList", &repos, gql.Arguments{}); err != nil {
+		resp.Diagnostics.AddError("Failed to read borg repos", err.Error())
+		return
+	}
+	data.update(payload, repos)
+
+	tflog.Trace(ctx, "read account", map[string]interface{}{
+		"id":    data.Id,
+		"email": data.Email,
+	})
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccAccountDataSource(t *testing.T) {
+	id := "data.borgbase_account.test"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Read testing
+			{
+				Config: testAccAccountDataSourceConfig,
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestMatchResourceAttr(id, "email", regexp.MustCompile(`.+@.+`)),
+					resource.TestCheckResourceAttrSet(id, "id"),
+					resource.TestCheckResourceAttrSet(id, "plan"),
+					resource.TestCheckResourceAttrSet(id, "repo_count"),
+					resource.TestCheckResourceAttrSet(id, "repo_limit"),
+					resource.TestCheckResourceAttrSet(id, "storage_limit"),
+					resource.TestCheckResourceAttrSet(id, "total_quota"),
+					resource.TestCheckResourceAttrSet(id, "total_usage"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccountPayloadMatches(t *testing.T) {
+	account := AccountPayload{Id: "42", Email: "Backups@example.com"}
+
+	for expected, matches := range map[string]bool{
+		"42":                  true,
+		"backups@example.com": true,
+		"Backups@example.com": true,
+		"43":                  false,
+		"other@example.com":   false,
+	} {
+		if actual := account.matches(expected); actual != matches {
+			t.Errorf("matches(%q): expected %t, got %t", expected, matches, actual)
+		}
+	}
+}
+
+func TestAccountModelUpdate(t *testing.T) {
+	var account AccountPayload
+	account.Plan.Name = "Medium"
+	account.Plan.RepoLimit = 20
+	account.Plan.StorageLimit = 1000000
+
+	var data AccountModel
+	data.update(account, BorgReposPayload{
+		{Quota: 1000, QuotaEnabled: true, CurrentUsage: 12.5},
+		{Quota: 5000, QuotaEnabled: false, CurrentUsage: 0.5},
+	})
+
+	if data.Plan.ValueString() != "Medium" {
+		t.Errorf("plan: expected Medium, got %s", data.Plan)
+	}
+	if data.RepoCount.ValueInt64() != 2 {
+		t.Errorf("repo_count: expected 2, got %s", data.RepoCount)
+	}
+	if data.RepoLimit.ValueInt64() != 20 {
+		t.Errorf("repo_limit: expected 20, got %s", data.RepoLimit)
+	}
+	if data.StorageLimit.ValueInt64() != 1000000 {
+		t.Errorf("storage_limit: expected 1000000, got %s", data.StorageLimit)
+	}
+	if data.TotalQuota.ValueInt64() != 1000 {
+		t.Errorf("total_quota: expected 1000, got %s", data.TotalQuota)
+	}
+	if data.TotalUsage.ValueFloat64() != 13 {
+		t.Errorf("total_usage: expected 13, got %s", data.TotalUsage)
+	}
+}
+
+const testAccAccountDataSourceConfig = `
+data "borgbase_account" "test" {}
+`
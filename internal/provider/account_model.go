@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type AccountModel struct {
+	Email        types.String  `tfsdk:"email"`
+	Id           types.String  `tfsdk:"id"`
+	Name         types.String  `tfsdk:"name"`
+	Plan         types.String  `tfsdk:"plan"`
+	RepoCount    types.Int64   `tfsdk:"repo_count"`
+	RepoLimit    types.Int64   `tfsdk:"repo_limit"`
+	StorageLimit types.Int64   `tfsdk:"storage_limit"`
+	TotalQuota   types.Int64   `tfsdk:"total_quota"`
+	TotalUsage   types.Float64 `tfsdk:"total_usage"`
+}
+
+func (m *AccountModel) update(account AccountPayload, repos BorgReposPayload) {
+	m.Email = types.StringValue(account.Email)
+	m.Id = types.StringValue(account.Id)
+	m.Name = types.StringValue(account.Name)
+	m.Plan = types.StringValue(account.Plan.Name)
+	m.RepoLimit = types.Int64Value(int64(account.Plan.RepoLimit))
+	m.StorageLimit = types.Int64Value(int64(account.Plan.StorageLimit))
+
+	var totalQuota int64
+	var totalUsage float64
+	for _, repo := range repos {
+		if repo.QuotaEnabled {
+			totalQuota += int64(repo.Quota)
+		}
+		totalUsage += repo.CurrentUsage
+	}
+	m.RepoCount = types.Int64Value(int64(len(repos)))
+	m.TotalQuota = types.Int64Value(totalQuota)
+	m.TotalUsage = types.Float64Value(totalUsage)
+}
+
+type AccountPayload struct {
+	Id    string `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	Plan  struct {
+		Name         string `json:"name"`
+		RepoLimit    int    `json:"repoLimit"`
+		StorageLimit int    `json:"storageLimit"`
+	} `json:"plan"`
+}
+
+// matches returns whether the account is identified by the given email
+// address (case-insensitive) or account ID.
+func (p AccountPayload) matches(account string) bool {
+	return strings.EqualFold(p.Email, account) || p.Id == account
+}
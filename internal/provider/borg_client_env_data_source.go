@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &BorgClientEnvDataSource{}
+
+func NewBorgClientEnvDataSource() datasource.DataSource {
+	return &BorgClientEnvDataSource{}
+}
+
+type BorgClientEnvDataSource struct {
+	repoLookupDataSource
+}
+
+func (d *BorgClientEnvDataSource) Metadata(
+	ctx context.Context,
+	req datasource.MetadataRequest,
+	resp *datasource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_borg_client_env"
+}
+
+func (d *BorgClientEnvDataSource) Schema(
+	ctx context.Context,
+	req datasource.SchemaRequest,
+	resp *datasource.SchemaResponse,
+) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Environment for running borg against a borg repository.",
+		Attributes: withRepoLookupAttributes(map[string]schema.Attribute{
+			"env": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				MarkdownDescription: "Environment variables for borg (`BORG_REPO` and `BORG_RSH`).",
+			},
+			"host_public_key": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Public host key of the repository server in OpenSSH format, e.g. from `ssh-keyscan`. It must match the fingerprint of the same type reported by BorgBase.",
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Internal BorgBase repository identifier.",
+			},
+			"known_hosts": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "known_hosts line trusting `host_public_key` for the repository server (null if `host_public_key` is not set).",
+			},
+			"known_hosts_file": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Path of the known_hosts file which `BORG_RSH` should use (defaults to SSH's default files).",
+			},
+			"path": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Path of the repository on the server.",
+			},
+			"repo_path": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "SSH path where the repository can be accessed.",
+			},
+			"ssh_host": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Hostname of the repository server.",
+			},
+			"ssh_key_file": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Path of the private SSH key which `BORG_RSH` should use (defaults to SSH's default keys).",
+			},
+			"ssh_port": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "SSH port of the repository server.",
+			},
+			"ssh_user": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "SSH user for accessing the repository.",
+			},
+		}),
+	}
+}
+
+func (d *BorgClientEnvDataSource) Read(
+	ctx context.Context,
+	req datasource.ReadRequest,
+	resp *datasource.ReadResponse,
+) {
+	var data BorgClientEnvModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	repo := d.readRepo(ctx, data.RepoId, data.RepoName, &resp.Diagnostics)
+	if repo == nil {
+		return
+	}
+	if !repo.ready() {
+		resp.Diagnostics.AddError(
+			"Borg repo not ready",
+			fmt.Sprintf("Borg repo %s has not been assigned to a server yet.", repo.Name),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(data.update(ctx, *repo)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Trace(ctx, "read borg client env", map[string]interface{}{
+		"id":   data.Id,
+		"name": data.RepoName,
+	})
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
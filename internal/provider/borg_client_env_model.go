@@ -0,0 +1,141 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+type BorgClientEnvModel struct {
+	Env            types.Map    `tfsdk:"env"`
+	HostPublicKey  types.String `tfsdk:"host_public_key"`
+	Id             types.String `tfsdk:"id"`
+	KnownHosts     types.String `tfsdk:"known_hosts"`
+	KnownHostsFile types.String `tfsdk:"known_hosts_file"`
+	Path           types.String `tfsdk:"path"`
+	RepoId         types.String `tfsdk:"repo_id"`
+	RepoName       types.String `tfsdk:"repo_name"`
+	RepoPath       types.String `tfsdk:"repo_path"`
+	SshHost        types.String `tfsdk:"ssh_host"`
+	SshKeyFile     types.String `tfsdk:"ssh_key_file"`
+	SshPort        types.Int64  `tfsdk:"ssh_port"`
+	SshUser        types.String `tfsdk:"ssh_user"`
+}
+
+// update sets the computed attributes from the given repo. If a host public
+// key is configured, it must match one of the server's fingerprints.
+func (m *BorgClientEnvModel) update(
+	ctx context.Context,
+	repo BorgRepoPayload,
+) diag.Diagnostics {
+	var diagnostics diag.Diagnostics
+
+	parsed, err := parseRepoPath(repo.RepoPath)
+	if err != nil {
+		diagnostics.AddError("Failed to parse repo path", err.Error())
+		return diagnostics
+	}
+
+	m.Id = types.StringValue(repo.Id)
+	m.Path = types.StringValue(parsed.Path)
+	m.RepoId = types.StringValue(repo.Id)
+	m.RepoName = types.StringValue(repo.Name)
+	m.RepoPath = types.StringValue(repo.RepoPath)
+	m.SshHost = types.StringValue(parsed.Host)
+	m.SshPort = types.Int64Value(parsed.Port)
+	m.SshUser = types.StringValue(parsed.User)
+
+	env, envDiagnostics := types.MapValueFrom(ctx, types.StringType, map[string]string{
+		"BORG_REPO": repo.RepoPath,
+		"BORG_RSH": sshCommand(
+			m.SshKeyFile.ValueString(),
+			m.KnownHostsFile.ValueString(),
+		),
+	})
+	diagnostics.Append(envDiagnostics...)
+	if diagnostics.HasError() {
+		return diagnostics
+	}
+	m.Env = env
+
+	m.KnownHosts = types.StringNull()
+	if m.HostPublicKey.IsNull() {
+		return diagnostics
+	}
+
+	line, err := knownHostsLine(parsed, repo, m.HostPublicKey.ValueString())
+	if err != nil {
+		diagnostics.AddAttributeError(
+			path.Root("host_public_key"),
+			"Untrusted host public key",
+			err.Error(),
+		)
+		return diagnostics
+	}
+	m.KnownHosts = types.StringValue(line)
+	return diagnostics
+}
+
+// knownHostsLine verifies the given host public key against the fingerprint
+// of the same type reported by BorgBase for the repo's server, and returns a
+// known_hosts line trusting it.
+func knownHostsLine(
+	parsed repoPath,
+	repo BorgRepoPayload,
+	hostPublicKey string,
+) (string, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostPublicKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse host public key: %w", err)
+	}
+
+	var fingerprint string
+	switch {
+	case key.Type() == ssh.KeyAlgoED25519:
+		fingerprint = repo.Server.FingerprintEd25519
+	case strings.HasPrefix(key.Type(), "ecdsa-sha2-"):
+		fingerprint = repo.Server.FingerprintEcdsa
+	case key.Type() == ssh.KeyAlgoRSA:
+		fingerprint = repo.Server.FingerprintRsa
+	default:
+		return "", fmt.Errorf("host public keys of type %s are not supported, "+
+			"expected an ED25519, ECDSA or RSA key", key.Type())
+	}
+	if fingerprint == "" {
+		return "", fmt.Errorf("BorgBase hasn't reported a fingerprint for the "+
+			"%s host key of server %s yet", key.Type(), parsed.Host)
+	}
+	if !matchesFingerprint(key, fingerprint) {
+		return "", fmt.Errorf("host public key has fingerprint %s, which "+
+			"doesn't match the fingerprint %s reported by BorgBase for server %s",
+			ssh.FingerprintSHA256(key), fingerprint, parsed.Host)
+	}
+
+	address := net.JoinHostPort(parsed.Host, strconv.FormatInt(parsed.Port, 10))
+	return knownhosts.Line([]string{knownhosts.Normalize(address)}, key), nil
+}
+
+// matchesFingerprint returns whether the key has the given fingerprint,
+// which may be either a SHA256 fingerprint with or without the "SHA256:"
+// prefix and padding, or a legacy colon-separated MD5 fingerprint.
+func matchesFingerprint(key ssh.PublicKey, fingerprint string) bool {
+	fingerprint = strings.TrimSpace(fingerprint)
+
+	var expected string
+	if strings.Count(fingerprint, ":") == 15 || strings.HasPrefix(fingerprint, "MD5:") {
+		fingerprint = strings.ToLower(strings.TrimPrefix(fingerprint, "MD5:"))
+		expected = ssh.FingerprintLegacyMD5(key)
+	} else {
+		fingerprint = strings.TrimRight(strings.TrimPrefix(fingerprint, "SHA256:"), "=")
+		expected = strings.TrimPrefix(ssh.FingerprintSHA256(key), "SHA256:")
+	}
+	return fingerprint == expected
+}
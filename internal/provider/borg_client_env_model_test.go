@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"golang.org/x/crypto/ssh"
+)
+
+func testHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestBorgClientEnvModelUpdate(t *testing.T) {
+	ctx := context.Background()
+	hostKey := testHostKey(t)
+	otherKey := testHostKey(t)
+	authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(hostKey)))
+
+	repo := BorgRepoPayload{
+		Id:       "abc",
+		Name:     "web",
+		RepoPath: "ssh://abc123@abc123.repo.borgbase.com/./repo",
+	}
+	repo.Server.FingerprintEd25519 = ssh.FingerprintSHA256(hostKey)
+
+	for name, tc := range map[string]struct {
+		hostPublicKey types.String
+		fingerprint   string
+		repoPath      string
+		knownHosts    types.String
+		err           bool
+	}{
+		"no host key": {
+			hostPublicKey: types.StringNull(),
+			knownHosts:    types.StringNull(),
+		},
+		"sha256 fingerprint": {
+			hostPublicKey: types.StringValue(authorizedKey + " root@server"),
+			knownHosts:    types.StringValue("abc123.repo.borgbase.com " + authorizedKey),
+		},
+		"unprefixed sha256 fingerprint": {
+			hostPublicKey: types.StringValue(authorizedKey),
+			fingerprint:   strings.TrimPrefix(ssh.FingerprintSHA256(hostKey), "SHA256:") + "=",
+			knownHosts:    types.StringValue("abc123.repo.borgbase.com " + authorizedKey),
+		},
+		"md5 fingerprint": {
+			hostPublicKey: types.StringValue(authorizedKey),
+			fingerprint:   strings.ToUpper(ssh.FingerprintLegacyMD5(hostKey)),
+			knownHosts:    types.StringValue("abc123.repo.borgbase.com " + authorizedKey),
+		},
+		"custom port": {
+			hostPublicKey: types.StringValue(authorizedKey),
+			repoPath:      "ssh://abc123@abc123.repo.borgbase.com:2222/./repo",
+			knownHosts:    types.StringValue("[abc123.repo.borgbase.com]:2222 " + authorizedKey),
+		},
+		"mismatched key": {
+			hostPublicKey: types.StringValue(
+				string(ssh.MarshalAuthorizedKey(otherKey)),
+			),
+			err: true,
+		},
+		"invalid key": {
+			hostPublicKey: types.StringValue("ssh-ed25519 invalid"),
+			err:           true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			repo := repo
+			if tc.fingerprint != "" {
+				repo.Server.FingerprintEd25519 = tc.fingerprint
+			}
+			if tc.repoPath != "" {
+				repo.RepoPath = tc.repoPath
+			}
+
+			data := BorgClientEnvModel{
+				HostPublicKey:  tc.hostPublicKey,
+				KnownHostsFile: types.StringNull(),
+				SshKeyFile:     types.StringValue("/root/.ssh/borgbase"),
+			}
+			diagnostics := data.update(ctx, repo)
+			if tc.err {
+				if !diagnostics.HasError() {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if diagnostics.HasError() {
+				t.Fatal(diagnostics)
+			}
+
+			if !data.KnownHosts.Equal(tc.knownHosts) {
+				t.Errorf("known_hosts: expected %s, got %s", tc.knownHosts, data.KnownHosts)
+			}
+			if data.SshUser.ValueString() != "abc123" {
+				t.Errorf("ssh_user: expected abc123, got %s", data.SshUser)
+			}
+			env := data.Env.Elements()
+			if env["BORG_REPO"] != types.StringValue(repo.RepoPath) {
+				t.Errorf("BORG_REPO: expected %s, got %s", repo.RepoPath, env["BORG_REPO"])
+			}
+			expected := types.StringValue(
+				"ssh -o StrictHostKeyChecking=yes -o IdentitiesOnly=yes -i /root/.ssh/borgbase",
+			)
+			if env["BORG_RSH"] != expected {
+				t.Errorf("BORG_RSH: expected %s, got %s", expected, env["BORG_RSH"])
+			}
+		})
+	}
+}
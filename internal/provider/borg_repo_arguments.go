@@ -0,0 +1,218 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/gjabell/terraform-provider-borgbase/gql"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// argumentState describes whether a repo attribute should be sent to the
+// BorgBase API.
+type argumentState int
+
+const (
+	// argumentUnknown is an attribute whose value is not known yet, e.g.
+	// because the user left an optional+computed attribute unset. It is never
+	// sent, so BorgBase keeps its current or default value.
+	argumentUnknown argumentState = iota
+	// argumentNull is an attribute which is explicitly null. It is not sent
+	// either, as the API has no way to reset a field.
+	argumentNull
+	// argumentSet is an attribute with a known value, which is sent as-is.
+	argumentSet
+)
+
+func (s argumentState) String() string {
+	switch s {
+	case argumentUnknown:
+		return "unknown"
+	case argumentNull:
+		return "null"
+	default:
+		return "set"
+	}
+}
+
+// repoArgument is a single repoAdd/repoEdit argument built from a repo
+// attribute.
+type repoArgument struct {
+	State argumentState
+	Value interface{}
+}
+
+// newRepoArgument returns the argument for the given attribute, calling value
+// to get its API representation only if the attribute is set.
+func newRepoArgument(v attr.Value, value func() interface{}) repoArgument {
+	switch {
+	case v.IsUnknown():
+		return repoArgument{State: argumentUnknown}
+	case v.IsNull():
+		return repoArgument{State: argumentNull}
+	default:
+		return repoArgument{State: argumentSet, Value: value()}
+	}
+}
+
+func int64Argument(v types.Int64) repoArgument {
+	return newRepoArgument(v, func() interface{} { return v.ValueInt64() })
+}
+
+func boolArgument(v types.Bool) repoArgument {
+	return newRepoArgument(v, func() interface{} { return v.ValueBool() })
+}
+
+func stringArgument(v types.String) repoArgument {
+	return newRepoArgument(v, func() interface{} { return v.ValueString() })
+}
+
+// setArgument returns the sorted elements of a set of strings, so that sets
+// with the same elements always produce the same argument.
+func setArgument(
+	ctx context.Context,
+	v types.Set,
+	diagnostics *diag.Diagnostics,
+) repoArgument {
+	return newRepoArgument(v, func() interface{} {
+		keys := []string{}
+		diagnostics.Append(v.ElementsAs(ctx, &keys, false)...)
+		sort.Strings(keys)
+		return keys
+	})
+}
+
+// repoArguments returns the state of every optional repoAdd/repoEdit argument
+// for the given repo, keyed by API argument name.
+func repoArguments(
+	ctx context.Context,
+	data BorgRepoModel,
+) (map[string]repoArgument, diag.Diagnostics) {
+	var diagnostics diag.Diagnostics
+
+	args := map[string]repoArgument{
+		"alertDays":      int64Argument(data.AlertDays),
+		"appendOnly":     boolArgument(data.AppendOnly),
+		"appendOnlyKeys": setArgument(ctx, data.AppendOnlyKeys, &diagnostics),
+		"borgVersion":    stringArgument(data.BorgVersion),
+		"fullAccessKeys": setArgument(ctx, data.FullAccessKeys, &diagnostics),
+		"quota":          int64Argument(data.Quota),
+		"quotaEnabled":   boolArgument(data.QuotaEnabled),
+		"rsyncKeys":      setArgument(ctx, data.RsyncKeys, &diagnostics),
+		"sftpEnabled":    boolArgument(data.SftpEnabled),
+	}
+
+	// The compaction fields are set together, so they share the state of the
+	// compaction object unless an individual field is unknown.
+	if data.Compaction.IsNull() || data.Compaction.IsUnknown() {
+		arg := newRepoArgument(data.Compaction, nil)
+		for _, name := range compactionArguments {
+			args[name] = arg
+		}
+	} else {
+		var compaction CompactionModel
+		diagnostics.Append(data.Compaction.As(
+			ctx,
+			&compaction,
+			basetypes.ObjectAsOptions{},
+		)...)
+		if diagnostics.HasError() {
+			return nil, diagnostics
+		}
+		args["compactionEnabled"] = boolArgument(compaction.Enabled)
+		args["compactionHour"] = int64Argument(compaction.Hour)
+		args["compactionHourTimezone"] = stringArgument(compaction.HourTimezone)
+		args["compactionInterval"] = int64Argument(compaction.Interval)
+		args["compactionIntervalUnit"] = stringArgument(compaction.IntervalUnit)
+	}
+
+	return args, diagnostics
+}
+
+// setArguments adds every set repo argument to args. Unknown and null
+// attributes are left out, so BorgBase keeps their current value.
+func setArguments(
+	ctx context.Context,
+	args gql.Arguments,
+	data BorgRepoModel,
+) diag.Diagnostics {
+	repoArgs, diagnostics := repoArguments(ctx, data)
+	if diagnostics.HasError() {
+		return diagnostics
+	}
+
+	for name, arg := range repoArgs {
+		if arg.State == argumentSet {
+			args[name] = gql.Optional(arg.Value)
+		}
+	}
+	return diagnostics
+}
+
+// compactionArguments are the arguments making up the compaction settings,
+// which are always sent together.
+var compactionArguments = []string{
+	"compactionEnabled",
+	"compactionHour",
+	"compactionHourTimezone",
+	"compactionInterval",
+	"compactionIntervalUnit",
+}
+
+// setChangedArguments adds every repo argument which is set in the plan and
+// differs from the prior state to args, so that edits made outside of
+// Terraform to other attributes are left alone. If any compaction setting has
+// changed, all compaction settings are sent.
+func setChangedArguments(
+	ctx context.Context,
+	args gql.Arguments,
+	plan BorgRepoModel,
+	state BorgRepoModel,
+) diag.Diagnostics {
+	planArgs, diagnostics := repoArguments(ctx, plan)
+	if diagnostics.HasError() {
+		return diagnostics
+	}
+	stateArgs, stateDiagnostics := repoArguments(ctx, state)
+	diagnostics.Append(stateDiagnostics...)
+	if diagnostics.HasError() {
+		return diagnostics
+	}
+
+	if !plan.Name.Equal(state.Name) {
+		args["name"] = gql.Optional(plan.Name.ValueString())
+	}
+
+	changed := func(name string) bool {
+		return planArgs[name].State == argumentSet &&
+			!reflect.DeepEqual(planArgs[name], stateArgs[name])
+	}
+
+	compactionChanged := false
+	for _, name := range compactionArguments {
+		compactionChanged = compactionChanged || changed(name)
+	}
+
+	for name, arg := range planArgs {
+		if arg.State != argumentSet {
+			continue
+		}
+		if changed(name) || (compactionChanged && isCompactionArgument(name)) {
+			args[name] = gql.Optional(arg.Value)
+		}
+	}
+	return diagnostics
+}
+
+func isCompactionArgument(name string) bool {
+	for _, arg := range compactionArguments {
+		if arg == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,357 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gjabell/terraform-provider-borgbase/gql"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// testNullBorgRepoModel returns a repo model in which every attribute is null.
+func testNullBorgRepoModel() BorgRepoModel {
+	return BorgRepoModel{
+		AlertDays:          types.Int64Null(),
+		AppendOnly:         types.BoolNull(),
+		AppendOnlyKeys:     types.SetNull(types.StringType),
+		AppendOnlyKeyNames: types.SetNull(types.StringType),
+		BorgVersion:        types.StringNull(),
+		Compaction:         types.ObjectNull(compactionAttributes),
+		FullAccessKeys:     types.SetNull(types.StringType),
+		FullAccessKeyNames: types.SetNull(types.StringType),
+		Quota:              types.Int64Null(),
+		QuotaEnabled:       types.BoolNull(),
+		RsyncKeys:          types.SetNull(types.StringType),
+		RsyncKeyNames:      types.SetNull(types.StringType),
+		SftpEnabled:        types.BoolNull(),
+	}
+}
+
+func testCompaction(enabled attr.Value) types.Object {
+	return types.ObjectValueMust(compactionAttributes, map[string]attr.Value{
+		"enabled":       enabled,
+		"hour":          types.Int64Value(14),
+		"hour_timezone": types.StringValue("Europe/Berlin"),
+		"interval":      types.Int64Value(6),
+		"interval_unit": types.StringValue("weeks"),
+	})
+}
+
+func TestRepoArguments(t *testing.T) {
+	keys := types.SetValueMust(
+		types.StringType,
+		[]attr.Value{types.StringValue("2"), types.StringValue("1")},
+	)
+
+	for name, tc := range map[string]struct {
+		set      func(*BorgRepoModel)
+		expected map[string]repoArgument
+	}{
+		"alert_days unknown": {
+			set:      func(m *BorgRepoModel) { m.AlertDays = types.Int64Unknown() },
+			expected: map[string]repoArgument{"alertDays": {State: argumentUnknown}},
+		},
+		"alert_days zero": {
+			set: func(m *BorgRepoModel) { m.AlertDays = types.Int64Value(0) },
+			expected: map[string]repoArgument{
+				"alertDays": {State: argumentSet, Value: int64(0)},
+			},
+		},
+		"alert_days set": {
+			set: func(m *BorgRepoModel) { m.AlertDays = types.Int64Value(2) },
+			expected: map[string]repoArgument{
+				"alertDays": {State: argumentSet, Value: int64(2)},
+			},
+		},
+		"append_only unknown": {
+			set:      func(m *BorgRepoModel) { m.AppendOnly = types.BoolUnknown() },
+			expected: map[string]repoArgument{"appendOnly": {State: argumentUnknown}},
+		},
+		"append_only false": {
+			set: func(m *BorgRepoModel) { m.AppendOnly = types.BoolValue(false) },
+			expected: map[string]repoArgument{
+				"appendOnly": {State: argumentSet, Value: false},
+			},
+		},
+		"append_only_keys unknown": {
+			set: func(m *BorgRepoModel) {
+				m.AppendOnlyKeys = types.SetUnknown(types.StringType)
+			},
+			expected: map[string]repoArgument{
+				"appendOnlyKeys": {State: argumentUnknown},
+			},
+		},
+		"append_only_keys empty": {
+			set: func(m *BorgRepoModel) {
+				m.AppendOnlyKeys = types.SetValueMust(types.StringType, nil)
+			},
+			expected: map[string]repoArgument{
+				"appendOnlyKeys": {State: argumentSet, Value: []string{}},
+			},
+		},
+		"append_only_keys set": {
+			set: func(m *BorgRepoModel) { m.AppendOnlyKeys = keys },
+			expected: map[string]repoArgument{
+				"appendOnlyKeys": {State: argumentSet, Value: []string{"1", "2"}},
+			},
+		},
+		"borg_version unknown": {
+			set:      func(m *BorgRepoModel) { m.BorgVersion = types.StringUnknown() },
+			expected: map[string]repoArgument{"borgVersion": {State: argumentUnknown}},
+		},
+		"borg_version set": {
+			set: func(m *BorgRepoModel) { m.BorgVersion = types.StringValue("LATEST") },
+			expected: map[string]repoArgument{
+				"borgVersion": {State: argumentSet, Value: "LATEST"},
+			},
+		},
+		"compaction unknown": {
+			set: func(m *BorgRepoModel) {
+				m.Compaction = types.ObjectUnknown(compactionAttributes)
+			},
+			expected: map[string]repoArgument{
+				"compactionEnabled":      {State: argumentUnknown},
+				"compactionHour":         {State: argumentUnknown},
+				"compactionHourTimezone": {State: argumentUnknown},
+				"compactionInterval":     {State: argumentUnknown},
+				"compactionIntervalUnit": {State: argumentUnknown},
+			},
+		},
+		"compaction field unknown": {
+			set: func(m *BorgRepoModel) {
+				m.Compaction = testCompaction(types.BoolUnknown())
+			},
+			expected: map[string]repoArgument{
+				"compactionEnabled":      {State: argumentUnknown},
+				"compactionHour":         {State: argumentSet, Value: int64(14)},
+				"compactionHourTimezone": {State: argumentSet, Value: "Europe/Berlin"},
+				"compactionInterval":     {State: argumentSet, Value: int64(6)},
+				"compactionIntervalUnit": {State: argumentSet, Value: "weeks"},
+			},
+		},
+		"compaction set": {
+			set: func(m *BorgRepoModel) {
+				m.Compaction = testCompaction(types.BoolValue(true))
+			},
+			expected: map[string]repoArgument{
+				"compactionEnabled":      {State: argumentSet, Value: true},
+				"compactionHour":         {State: argumentSet, Value: int64(14)},
+				"compactionHourTimezone": {State: argumentSet, Value: "Europe/Berlin"},
+				"compactionInterval":     {State: argumentSet, Value: int64(6)},
+				"compactionIntervalUnit": {State: argumentSet, Value: "weeks"},
+			},
+		},
+		"full_access_keys unknown": {
+			set: func(m *BorgRepoModel) {
+				m.FullAccessKeys = types.SetUnknown(types.StringType)
+			},
+			expected: map[string]repoArgument{
+				"fullAccessKeys": {State: argumentUnknown},
+			},
+		},
+		"full_access_keys set": {
+			set: func(m *BorgRepoModel) { m.FullAccessKeys = keys },
+			expected: map[string]repoArgument{
+				"fullAccessKeys": {State: argumentSet, Value: []string{"1", "2"}},
+			},
+		},
+		"quota unknown": {
+			set:      func(m *BorgRepoModel) { m.Quota = types.Int64Unknown() },
+			expected: map[string]repoArgument{"quota": {State: argumentUnknown}},
+		},
+		"quota set": {
+			set: func(m *BorgRepoModel) { m.Quota = types.Int64Value(10000) },
+			expected: map[string]repoArgument{
+				"quota": {State: argumentSet, Value: int64(10000)},
+			},
+		},
+		"quota_enabled unknown": {
+			set:      func(m *BorgRepoModel) { m.QuotaEnabled = types.BoolUnknown() },
+			expected: map[string]repoArgument{"quotaEnabled": {State: argumentUnknown}},
+		},
+		"quota_enabled set": {
+			set: func(m *BorgRepoModel) { m.QuotaEnabled = types.BoolValue(true) },
+			expected: map[string]repoArgument{
+				"quotaEnabled": {State: argumentSet, Value: true},
+			},
+		},
+		"rsync_keys unknown": {
+			set: func(m *BorgRepoModel) {
+				m.RsyncKeys = types.SetUnknown(types.StringType)
+			},
+			expected: map[string]repoArgument{"rsyncKeys": {State: argumentUnknown}},
+		},
+		"rsync_keys set": {
+			set: func(m *BorgRepoModel) { m.RsyncKeys = keys },
+			expected: map[string]repoArgument{
+				"rsyncKeys": {State: argumentSet, Value: []string{"1", "2"}},
+			},
+		},
+		"sftp_enabled unknown": {
+			set:      func(m *BorgRepoModel) { m.SftpEnabled = types.BoolUnknown() },
+			expected: map[string]repoArgument{"sftpEnabled": {State: argumentUnknown}},
+		},
+		"sftp_enabled set": {
+			set: func(m *BorgRepoModel) { m.SftpEnabled = types.BoolValue(true) },
+			expected: map[string]repoArgument{
+				"sftpEnabled": {State: argumentSet, Value: true},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data := testNullBorgRepoModel()
+			tc.set(&data)
+
+			args, diagnostics := repoArguments(context.Background(), data)
+			if diagnostics.HasError() {
+				t.Fatal(diagnostics)
+			}
+
+			for name, arg := range args {
+				expected, ok := tc.expected[name]
+				if !ok {
+					expected = repoArgument{State: argumentNull}
+				}
+				if !reflect.DeepEqual(arg, expected) {
+					t.Errorf("%s: expected %s %v, got %s %v",
+						name, expected.State, expected.Value, arg.State, arg.Value)
+				}
+			}
+			for name := range tc.expected {
+				if _, ok := args[name]; !ok {
+					t.Errorf("%s: missing argument", name)
+				}
+			}
+		})
+	}
+}
+
+func TestSetArguments(t *testing.T) {
+	data := testNullBorgRepoModel()
+	data.AlertDays = types.Int64Unknown()
+	data.AppendOnly = types.BoolValue(false)
+	data.Quota = types.Int64Value(0)
+
+	args := gql.Arguments{}
+	if diagnostics := setArguments(context.Background(), args, data); diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+
+	expected := map[string]interface{}{"appendOnly": false, "quota": int64(0)}
+	if len(args) != len(expected) {
+		t.Errorf("expected %d arguments, got %d: %v", len(expected), len(args), args)
+	}
+	for name, value := range expected {
+		if arg, ok := args[name]; !ok || arg.Value() != value {
+			t.Errorf("%s: expected %v, got %v", name, value, arg)
+		}
+	}
+}
+
+func TestSetChangedArguments(t *testing.T) {
+	state := testNullBorgRepoModel()
+	state.Id = types.StringValue("abc")
+	state.Name = types.StringValue("test")
+	state.AlertDays = types.Int64Value(2)
+	state.AppendOnly = types.BoolValue(false)
+	state.Compaction = testCompaction(types.BoolValue(false))
+	state.AppendOnlyKeys = types.SetValueMust(
+		types.StringType,
+		[]attr.Value{types.StringValue("3"), types.StringValue("4")},
+	)
+	state.FullAccessKeys = types.SetValueMust(
+		types.StringType,
+		[]attr.Value{types.StringValue("1")},
+	)
+	state.Quota = types.Int64Value(1000)
+	state.QuotaEnabled = types.BoolValue(true)
+
+	for name, tc := range map[string]struct {
+		set      func(*BorgRepoModel)
+		expected map[string]interface{}
+	}{
+		"no change": {
+			set:      func(m *BorgRepoModel) {},
+			expected: map[string]interface{}{},
+		},
+		"name": {
+			set:      func(m *BorgRepoModel) { m.Name = types.StringValue("renamed") },
+			expected: map[string]interface{}{"name": "renamed"},
+		},
+		"scalar": {
+			set:      func(m *BorgRepoModel) { m.AlertDays = types.Int64Value(3) },
+			expected: map[string]interface{}{"alertDays": int64(3)},
+		},
+		"unknown": {
+			set:      func(m *BorgRepoModel) { m.AlertDays = types.Int64Unknown() },
+			expected: map[string]interface{}{},
+		},
+		"newly set": {
+			set:      func(m *BorgRepoModel) { m.SftpEnabled = types.BoolValue(true) },
+			expected: map[string]interface{}{"sftpEnabled": true},
+		},
+		"set": {
+			set: func(m *BorgRepoModel) {
+				m.FullAccessKeys = types.SetValueMust(
+					types.StringType,
+					[]attr.Value{types.StringValue("2"), types.StringValue("1")},
+				)
+			},
+			expected: map[string]interface{}{"fullAccessKeys": []string{"1", "2"}},
+		},
+		"set reordered": {
+			set: func(m *BorgRepoModel) {
+				m.AppendOnlyKeys = types.SetValueMust(
+					types.StringType,
+					[]attr.Value{types.StringValue("4"), types.StringValue("3")},
+				)
+			},
+			expected: map[string]interface{}{},
+		},
+		"compaction": {
+			set: func(m *BorgRepoModel) {
+				m.Compaction = testCompaction(types.BoolValue(true))
+			},
+			expected: map[string]interface{}{
+				"compactionEnabled":      true,
+				"compactionHour":         int64(14),
+				"compactionHourTimezone": "Europe/Berlin",
+				"compactionInterval":     int64(6),
+				"compactionIntervalUnit": "weeks",
+			},
+		},
+		"multiple": {
+			set: func(m *BorgRepoModel) {
+				m.Quota = types.Int64Value(2000)
+				m.QuotaEnabled = types.BoolValue(false)
+				m.Name = types.StringValue("renamed")
+			},
+			expected: map[string]interface{}{
+				"name":         "renamed",
+				"quota":        int64(2000),
+				"quotaEnabled": false,
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			plan := state
+			tc.set(&plan)
+
+			args := gql.Arguments{}
+			diagnostics := setChangedArguments(context.Background(), args, plan, state)
+			if diagnostics.HasError() {
+				t.Fatal(diagnostics)
+			}
+
+			actual := make(map[string]interface{}, len(args))
+			for name, arg := range args {
+				actual[name] = arg.Value()
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -46,11 +46,20 @@ func (d *BorgRepoDataSource) Schema(
 				Computed:            true,
 				MarkdownDescription: "Whether the repository should allow old data to be deleted.",
 			},
-			"append_only_keys": schema.ListAttribute{
+			"append_only_keys": schema.SetAttribute{
 				ElementType:         types.StringType,
 				Computed:            true,
 				MarkdownDescription: "IDs of SSH keys which are only allowed to append data to the repository.",
 			},
+			"append_only_key_names": schema.SetAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				MarkdownDescription: "Names of SSH keys which are only allowed to append data to the repository.",
+			},
+			"borg_repo_url": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "URL of the repository for borg, e.g. `ssh://abc123@abc123.repo.borgbase.com/./repo`.",
+			},
 			"borg_version": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "Borg version to use for the repository (defaults to latest stable version).",
@@ -89,6 +98,14 @@ func (d *BorgRepoDataSource) Schema(
 				Computed:            true,
 				MarkdownDescription: "Current usage of the repository in megabytes.",
 			},
+			"current_usage_bytes": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Current usage of the repository in bytes.",
+			},
+			"current_usage_percent": schema.Float64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Current usage of the repository as a percentage of its quota (0 if there is no quota).",
+			},
 			"encryption": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "Whether the repository is encrypted.",
@@ -97,11 +114,16 @@ func (d *BorgRepoDataSource) Schema(
 				Computed:            true,
 				MarkdownDescription: "Format of the repository.",
 			},
-			"full_access_keys": schema.ListAttribute{
+			"full_access_keys": schema.SetAttribute{
 				ElementType:         types.StringType,
 				Computed:            true,
 				MarkdownDescription: "IDs of SSH keys which have full access to the repository.",
 			},
+			"full_access_key_names": schema.SetAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				MarkdownDescription: "Names of SSH keys which have full access to the repository.",
+			},
 			"id": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "Internal BorgBase repository identifier.",
@@ -122,6 +144,10 @@ func (d *BorgRepoDataSource) Schema(
 				Computed:            true,
 				MarkdownDescription: "Whether the repository quota should be enabled.",
 			},
+			"quota_size": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Max allowed size of the repository with a unit, e.g. `500GB` or `1TiB`.",
+			},
 			"region": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "Region where the repository is hosted (eu or us).",
@@ -130,11 +156,20 @@ func (d *BorgRepoDataSource) Schema(
 				Computed:            true,
 				MarkdownDescription: "SSH path where the repository can be accessed.",
 			},
-			"rsync_keys": schema.ListAttribute{
+			"rsync_keys": schema.SetAttribute{
 				ElementType:         types.StringType,
 				Computed:            true,
 				MarkdownDescription: "IDs of SSH keys which can access the repository via rsync.",
 			},
+			"rsync_key_names": schema.SetAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				MarkdownDescription: "Names of SSH keys which can access the repository via rsync.",
+			},
+			"rsync_url": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Location of the repository for rsync over SSH, e.g. `abc123@abc123.repo.borgbase.com:repo`. The port is not included, see `ssh_port`.",
+			},
 			"server": schema.SingleNestedAttribute{
 				Computed:            true,
 				MarkdownDescription: "Information about the server where the repository is hosted.",
@@ -177,6 +212,22 @@ func (d *BorgRepoDataSource) Schema(
 				Computed:            true,
 				MarkdownDescription: "Whether SFTP access to the repository should be enabled.",
 			},
+			"sftp_url": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "URL of the repository for SFTP clients (null if SFTP is disabled).",
+			},
+			"ssh_host": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Hostname of the repository server.",
+			},
+			"ssh_port": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "SSH port of the repository server.",
+			},
+			"ssh_user": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "SSH user for accessing the repository.",
+			},
 		},
 	}
 }
@@ -209,16 +260,18 @@ func (d *BorgRepoDataSource) Read(
 	req datasource.ReadRequest,
 	resp *datasource.ReadResponse,
 ) {
-	var data BorgRepoModel
+	var config BorgRepoDataSourceModel
 
-	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	data := BorgRepoModel{Name: config.Name}
+
 	var payload BorgReposPayload
 	args := gql.Arguments{"name": gql.Optional(data.Name.ValueString())}
-	if err := d.client.Query("repoList", &payload, args); err != nil {
+	if err := d.client.Query(ctx, "repoList", &payload, args); err != nil {
 		resp.Diagnostics.AddError("Failed to read borg repo", err.Error())
 		return
 	}
@@ -235,11 +288,27 @@ func (d *BorgRepoDataSource) Read(
 		return
 	}
 	resp.Diagnostics = append(resp.Diagnostics, data.update(ctx, *repo)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ids, err := listSshKeyIds(ctx, d.client)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read SSH keys", err.Error())
+		return
+	}
+	names := make(map[string]string)
+	for name, keyIds := range ids {
+		for _, id := range keyIds {
+			names[id] = name
+		}
+	}
+	resp.Diagnostics.Append(data.setKeyNames(ctx, names)...)
 
 	tflog.Trace(ctx, "read repo", map[string]interface{}{
 		"id":   data.Id,
 		"name": data.Name,
 	})
 
-	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, data.dataSourceModel())...)
 }
@@ -1,11 +1,18 @@
 package provider
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 )
 
@@ -74,9 +81,80 @@ func TestAccBorgRepoDataSource(t *testing.T) {
 	})
 }
 
+func TestBorgRepoDataSourceRead(t *testing.T) {
+	ctx := context.Background()
+
+	client, _ := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		switch req.Operation {
+		case "repoList":
+			return BorgReposPayload{{
+				Id:             "abc",
+				Name:           "test",
+				Region:         "eu",
+				BorgVersion:    "LATEST",
+				FullAccessKeys: []string{"1"},
+			}}, nil
+		case "sshList":
+			return []SshKeyPayload{{Id: "1", Name: "laptop"}}, nil
+		default:
+			return nil, fmt.Errorf("unexpected operation %s", req.Operation)
+		}
+	})
+
+	d := &BorgRepoDataSource{client: client}
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+
+	req := datasource.ReadRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw: testObjectValue(t, objectType, map[string]tftypes.Value{
+				"name": tftypes.NewValue(tftypes.String, "test"),
+			}),
+		},
+	}
+	resp := datasource.ReadResponse{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+			Raw:    tftypes.NewValue(objectType, nil),
+		},
+	}
+	d.Read(ctx, req, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+
+	var data BorgRepoDataSourceModel
+	if diagnostics := resp.State.Get(ctx, &data); diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+	if data.Id.ValueString() != "abc" {
+		t.Errorf("id: expected abc, got %s", data.Id)
+	}
+	expected := types.SetValueMust(types.StringType, []attr.Value{types.StringValue("laptop")})
+	if !data.FullAccessKeyNames.Equal(expected) {
+		t.Errorf("full_access_key_names: expected %s, got %s", expected, data.FullAccessKeyNames)
+	}
+}
+
+func TestBorgRepoModelDataSourceModel(t *testing.T) {
+	testDataSourceModelComplete(
+		t,
+		NewBorgRepoResource(),
+		NewBorgRepoDataSource(),
+		func(state tfsdk.State) (interface{}, diag.Diagnostics) {
+			var data BorgRepoModel
+			diagnostics := state.Get(context.Background(), &data)
+			return data.dataSourceModel(), diagnostics
+		},
+	)
+}
+
 func testAccBorgRepoDataSourceConfig(name string) string {
 	return fmt.Sprintf(`
 resource "borgbase_borg_repo" "test" {
+	deletion_protection = false
 	name = %[1]q
 	region = "eu"
 }
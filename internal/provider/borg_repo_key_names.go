@@ -0,0 +1,229 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gjabell/terraform-provider-borgbase/gql"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// keyAccessList is one of the SSH key access lists of a repo, which can be
+// configured either by key ID or by key name.
+type keyAccessList struct {
+	idAttribute   string
+	nameAttribute string
+	ids           *types.Set
+	names         *types.Set
+}
+
+func (m *BorgRepoModel) keyAccessLists() []keyAccessList {
+	return []keyAccessList{
+		{"full_access_keys", "full_access_key_names", &m.FullAccessKeys, &m.FullAccessKeyNames},
+		{"append_only_keys", "append_only_key_names", &m.AppendOnlyKeys, &m.AppendOnlyKeyNames},
+		{"rsync_keys", "rsync_key_names", &m.RsyncKeys, &m.RsyncKeyNames},
+	}
+}
+
+// checkKeyOverlap adds an error for every key which appears in more than one
+// of the given sets, as BorgBase silently picks one access level if a key is
+// listed more than once. Sets are keyed by attribute name.
+func checkKeyOverlap(
+	sets []keyAccessSet,
+	diagnostics *diag.Diagnostics,
+) {
+	seen := make(map[string]string)
+	for _, set := range sets {
+		if set.keys.IsNull() || set.keys.IsUnknown() {
+			continue
+		}
+		for _, key := range set.keys.Elements() {
+			value, ok := key.(types.String)
+			if !ok || value.IsNull() || value.IsUnknown() {
+				continue
+			}
+			id := value.ValueString()
+			if set.ids != nil {
+				id = set.ids[id]
+			}
+			if other, ok := seen[id]; ok {
+				diagnostics.AddAttributeError(
+					path.Root(set.attribute).AtSetValue(value),
+					"Conflicting SSH key access",
+					fmt.Sprintf("SSH key %s is listed in both %s and %s. "+
+						"Each key may only be granted one access level.",
+						value, other, set.attribute),
+				)
+				continue
+			}
+			seen[id] = set.attribute
+		}
+	}
+}
+
+// keyAccessSet is a set of SSH keys granted some access level. If ids is set,
+// the set contains key names which are mapped to IDs through it.
+type keyAccessSet struct {
+	attribute string
+	keys      types.Set
+	ids       map[string]string
+}
+
+// listSshKeyIds returns the IDs of all SSH keys in the account, keyed by
+// name. BorgBase accepts duplicate names, so a name may have several IDs.
+func listSshKeyIds(ctx context.Context, client *gql.Client) (map[string][]string, error) {
+	var payload SshKeysPayload
+	if err := client.Query(ctx, "sshList", &payload, gql.Arguments{}); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string][]string, len(payload))
+	for _, key := range payload {
+		ids[key.Name] = append(ids[key.Name], key.Id)
+	}
+	return ids, nil
+}
+
+// resolveKeyNames sets the key IDs of every access list configured by name
+// to the IDs of the named keys. Names which don't match any key are reported
+// as errors, unless planning: the keys may then be created in the same apply,
+// so the IDs of their list are left unknown to be resolved when applying, and
+// the names are reported as warnings. Names shared by several keys are always
+// reported as errors.
+func (m *BorgRepoModel) resolveKeyNames(
+	ctx context.Context,
+	client *gql.Client,
+	planning bool,
+) diag.Diagnostics {
+	var diagnostics diag.Diagnostics
+
+	var lists []keyAccessList
+	for _, list := range m.keyAccessLists() {
+		switch {
+		case list.names.IsUnknown():
+			*list.ids = types.SetUnknown(types.StringType)
+		case !list.names.IsNull():
+			lists = append(lists, list)
+		}
+	}
+	if len(lists) == 0 {
+		return diagnostics
+	}
+
+	ids, err := listSshKeyIds(ctx, client)
+	if err != nil {
+		diagnostics.AddError("Failed to read SSH keys", err.Error())
+		return diagnostics
+	}
+
+	var sets []keyAccessSet
+	keyNameIds := make(map[string]string)
+	for _, list := range lists {
+		var names []string
+		diagnostics.Append(list.names.ElementsAs(ctx, &names, false)...)
+		if diagnostics.HasError() {
+			return diagnostics
+		}
+
+		resolved := make([]string, 0, len(names))
+		missing := false
+		for _, name := range names {
+			namePath := path.Root(list.nameAttribute).AtSetValue(types.StringValue(name))
+			switch keyIds := ids[name]; {
+			case len(keyIds) == 0 && planning:
+				missing = true
+				diagnostics.AddAttributeWarning(
+					namePath,
+					"Unknown SSH key",
+					fmt.Sprintf("No SSH key named %q exists in the BorgBase account yet. "+
+						"It is resolved when applying, which fails if it still doesn't exist then.",
+						name),
+				)
+			case len(keyIds) == 0:
+				missing = true
+				diagnostics.AddAttributeError(
+					namePath,
+					"Unknown SSH key",
+					fmt.Sprintf("No SSH key named %q exists in the BorgBase account.",
+						name),
+				)
+			case len(keyIds) > 1:
+				diagnostics.AddAttributeError(
+					namePath,
+					"Ambiguous SSH key name",
+					fmt.Sprintf("SSH keys %s in the BorgBase account are all named %q. "+
+						"Rename or delete all but one of them, or configure the key by ID.",
+						strings.Join(keyIds, ", "), name),
+				)
+			default:
+				resolved = append(resolved, keyIds[0])
+				keyNameIds[name] = keyIds[0]
+			}
+		}
+		if missing && planning {
+			*list.ids = types.SetUnknown(types.StringType)
+			continue
+		}
+
+		var d diag.Diagnostics
+		*list.ids, d = types.SetValueFrom(ctx, types.StringType, resolved)
+		diagnostics.Append(d...)
+		sets = append(sets, keyAccessSet{list.nameAttribute, *list.names, keyNameIds})
+	}
+	if diagnostics.HasError() {
+		return diagnostics
+	}
+
+	// Keys configured by name may also be listed by ID in another list.
+	for _, list := range m.keyAccessLists() {
+		if list.names.IsNull() {
+			sets = append(sets, keyAccessSet{list.idAttribute, *list.ids, nil})
+		}
+	}
+	checkKeyOverlap(sets, &diagnostics)
+
+	return diagnostics
+}
+
+// hasUnresolvedKeyNames returns whether any access list is configured by name
+// but its key IDs were left unknown while planning.
+func (m *BorgRepoModel) hasUnresolvedKeyNames() bool {
+	for _, list := range m.keyAccessLists() {
+		if !list.names.IsNull() && list.ids.IsUnknown() {
+			return true
+		}
+	}
+	return false
+}
+
+// setKeyNames sets the key names of every access list from its key IDs,
+// using the given map of key IDs to names.
+func (m *BorgRepoModel) setKeyNames(
+	ctx context.Context,
+	names map[string]string,
+) diag.Diagnostics {
+	var diagnostics diag.Diagnostics
+
+	for _, list := range m.keyAccessLists() {
+		var ids []string
+		diagnostics.Append(list.ids.ElementsAs(ctx, &ids, false)...)
+		if diagnostics.HasError() {
+			return diagnostics
+		}
+
+		keyNames := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if name, ok := names[id]; ok {
+				keyNames = append(keyNames, name)
+			}
+		}
+
+		var d diag.Diagnostics
+		*list.names, d = types.SetValueFrom(ctx, types.StringType, keyNames)
+		diagnostics.Append(d...)
+	}
+	return diagnostics
+}
@@ -0,0 +1,204 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func testStringSet(values ...string) types.Set {
+	elements := make([]attr.Value, len(values))
+	for i, value := range values {
+		elements[i] = types.StringValue(value)
+	}
+	return types.SetValueMust(types.StringType, elements)
+}
+
+func TestBorgRepoModelResolveKeyNames(t *testing.T) {
+	client, api := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		if req.Operation != "sshList" {
+			return nil, fmt.Errorf("unexpected operation %s", req.Operation)
+		}
+		return SshKeysPayload{
+			{Id: "1", Name: "laptop"},
+			{Id: "2", Name: "server"},
+			{Id: "3", Name: "ci"},
+			{Id: "4", Name: "backup"},
+			{Id: "5", Name: "backup"},
+		}, nil
+	})
+
+	for name, tc := range map[string]struct {
+		set      func(*BorgRepoModel)
+		planning bool
+		expected map[string]types.Set
+		errors   []path.Path
+		warnings []path.Path
+		requests int
+	}{
+		"no names": {
+			set:      func(m *BorgRepoModel) {},
+			requests: 0,
+		},
+		"unknown names": {
+			set: func(m *BorgRepoModel) {
+				m.FullAccessKeyNames = types.SetUnknown(types.StringType)
+				m.FullAccessKeys = testStringSet("1")
+			},
+			planning: true,
+			expected: map[string]types.Set{
+				"full_access_keys": types.SetUnknown(types.StringType),
+			},
+			requests: 0,
+		},
+		"resolved": {
+			set: func(m *BorgRepoModel) {
+				m.AppendOnlyKeyNames = testStringSet("server", "ci")
+				m.AppendOnlyKeys = types.SetUnknown(types.StringType)
+				m.FullAccessKeyNames = testStringSet("laptop")
+				m.FullAccessKeys = types.SetUnknown(types.StringType)
+			},
+			expected: map[string]types.Set{
+				"append_only_keys": testStringSet("2", "3"),
+				"full_access_keys": testStringSet("1"),
+			},
+			requests: 1,
+		},
+		"mixed with IDs": {
+			set: func(m *BorgRepoModel) {
+				m.FullAccessKeys = testStringSet("1")
+				m.RsyncKeyNames = testStringSet("ci")
+				m.RsyncKeys = types.SetUnknown(types.StringType)
+			},
+			expected: map[string]types.Set{
+				"full_access_keys": testStringSet("1"),
+				"rsync_keys":       testStringSet("3"),
+			},
+			requests: 1,
+		},
+		"unknown key while planning": {
+			set: func(m *BorgRepoModel) {
+				m.FullAccessKeyNames = testStringSet("laptop", "desktop")
+				m.RsyncKeyNames = testStringSet("ci")
+			},
+			planning: true,
+			expected: map[string]types.Set{
+				"full_access_keys": types.SetUnknown(types.StringType),
+				"rsync_keys":       testStringSet("3"),
+			},
+			warnings: []path.Path{
+				path.Root("full_access_key_names").AtSetValue(types.StringValue("desktop")),
+			},
+			requests: 1,
+		},
+		"unknown key": {
+			set: func(m *BorgRepoModel) {
+				m.FullAccessKeyNames = testStringSet("laptop", "desktop")
+			},
+			errors: []path.Path{
+				path.Root("full_access_key_names").AtSetValue(types.StringValue("desktop")),
+			},
+			requests: 1,
+		},
+		"ambiguous name": {
+			set: func(m *BorgRepoModel) {
+				m.FullAccessKeyNames = testStringSet("laptop", "backup")
+			},
+			planning: true,
+			errors: []path.Path{
+				path.Root("full_access_key_names").AtSetValue(types.StringValue("backup")),
+			},
+			requests: 1,
+		},
+		"conflict with IDs": {
+			set: func(m *BorgRepoModel) {
+				m.FullAccessKeys = testStringSet("1")
+				m.RsyncKeyNames = testStringSet("laptop")
+			},
+			errors: []path.Path{
+				path.Root("full_access_keys").AtSetValue(types.StringValue("1")),
+			},
+			requests: 1,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			before := len(api.Requests())
+
+			data := testNullBorgRepoModel()
+			tc.set(&data)
+
+			diagnostics := data.resolveKeyNames(context.Background(), client, tc.planning)
+
+			if requests := len(api.Requests()) - before; requests != tc.requests {
+				t.Errorf("expected %d requests, got %d", tc.requests, requests)
+			}
+
+			errors := diagnostics.Errors()
+			if len(errors) != len(tc.errors) {
+				t.Fatalf("expected %d errors, got %d: %v",
+					len(tc.errors), len(errors), errors)
+			}
+			for i, expected := range tc.errors {
+				withPath, ok := errors[i].(diag.DiagnosticWithPath)
+				if !ok || !withPath.Path().Equal(expected) {
+					t.Errorf("expected error at %s, got %v", expected, errors[i])
+				}
+			}
+
+			warnings := diagnostics.Warnings()
+			if len(warnings) != len(tc.warnings) {
+				t.Fatalf("expected %d warnings, got %d: %v",
+					len(tc.warnings), len(warnings), warnings)
+			}
+			for i, expected := range tc.warnings {
+				withPath, ok := warnings[i].(diag.DiagnosticWithPath)
+				if !ok || !withPath.Path().Equal(expected) {
+					t.Errorf("expected warning at %s, got %v", expected, warnings[i])
+				}
+			}
+
+			actual := map[string]types.Set{
+				"append_only_keys": data.AppendOnlyKeys,
+				"full_access_keys": data.FullAccessKeys,
+				"rsync_keys":       data.RsyncKeys,
+			}
+			for name, expected := range tc.expected {
+				if !actual[name].Equal(expected) {
+					t.Errorf("%s: expected %s, got %s", name, expected, actual[name])
+				}
+			}
+		})
+	}
+}
+
+func TestBorgRepoModelSetKeyNames(t *testing.T) {
+	data := testNullBorgRepoModel()
+	data.AppendOnlyKeys = testStringSet()
+	data.FullAccessKeys = testStringSet("1", "2")
+	data.RsyncKeys = testStringSet("3")
+
+	diagnostics := data.setKeyNames(context.Background(), map[string]string{
+		"1": "laptop",
+		"2": "server",
+	})
+	if diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+
+	for name, tc := range map[string]struct {
+		actual, expected types.Set
+	}{
+		"append_only_key_names": {data.AppendOnlyKeyNames, testStringSet()},
+		"full_access_key_names": {data.FullAccessKeyNames, testStringSet("laptop", "server")},
+		"rsync_key_names":       {data.RsyncKeyNames, testStringSet()},
+	} {
+		if !tc.actual.Equal(tc.expected) {
+			t.Errorf("%s: expected %s, got %s", name, tc.expected, tc.actual)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package provider
 
 import (
 	"context"
+	"math"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
@@ -9,26 +11,116 @@ import (
 )
 
 type BorgRepoModel struct {
-	AlertDays      types.Int64   `tfsdk:"alert_days"`
-	AppendOnly     types.Bool    `tfsdk:"append_only"`
-	AppendOnlyKeys types.List    `tfsdk:"append_only_keys"`
-	BorgVersion    types.String  `tfsdk:"borg_version"`
-	Compaction     types.Object  `tfsdk:"compaction"`
-	CreatedAt      types.String  `tfsdk:"created_at"`
-	CurrentUsage   types.Float64 `tfsdk:"current_usage"`
-	Encryption     types.String  `tfsdk:"encryption"`
-	Format         types.String  `tfsdk:"format"`
-	FullAccessKeys types.List    `tfsdk:"full_access_keys"`
-	Id             types.String  `tfsdk:"id"`
-	LastModified   types.String  `tfsdk:"last_modified"`
-	Name           types.String  `tfsdk:"name"`
-	Quota          types.Int64   `tfsdk:"quota"`
-	QuotaEnabled   types.Bool    `tfsdk:"quota_enabled"`
-	Region         types.String  `tfsdk:"region"`
-	RepoPath       types.String  `tfsdk:"repo_path"`
-	RsyncKeys      types.List    `tfsdk:"rsync_keys"`
-	Server         types.Object  `tfsdk:"server"`
-	SftpEnabled    types.Bool    `tfsdk:"sftp_enabled"`
+	AlertDays           types.Int64   `tfsdk:"alert_days"`
+	AppendOnly          types.Bool    `tfsdk:"append_only"`
+	AppendOnlyKeys      types.Set     `tfsdk:"append_only_keys"`
+	AppendOnlyKeyNames  types.Set     `tfsdk:"append_only_key_names"`
+	BorgRepoUrl         types.String  `tfsdk:"borg_repo_url"`
+	BorgVersion         types.String  `tfsdk:"borg_version"`
+	Compaction          types.Object  `tfsdk:"compaction"`
+	CreatedAt           types.String  `tfsdk:"created_at"`
+	CurrentUsage        types.Float64 `tfsdk:"current_usage"`
+	CurrentUsageBytes   types.Int64   `tfsdk:"current_usage_bytes"`
+	CurrentUsagePercent types.Float64 `tfsdk:"current_usage_percent"`
+	DeletionProtection  types.Bool    `tfsdk:"deletion_protection"`
+	Encryption          types.String  `tfsdk:"encryption"`
+	ForceDestroy        types.Bool    `tfsdk:"force_destroy"`
+	Format              types.String  `tfsdk:"format"`
+	FullAccessKeys      types.Set     `tfsdk:"full_access_keys"`
+	FullAccessKeyNames  types.Set     `tfsdk:"full_access_key_names"`
+	Id                  types.String  `tfsdk:"id"`
+	LastModified        types.String  `tfsdk:"last_modified"`
+	Name                types.String  `tfsdk:"name"`
+	Quota               types.Int64   `tfsdk:"quota"`
+	QuotaEnabled        types.Bool    `tfsdk:"quota_enabled"`
+	QuotaSize           types.String  `tfsdk:"quota_size"`
+	Region              types.String  `tfsdk:"region"`
+	RepoPath            types.String  `tfsdk:"repo_path"`
+	RsyncKeys           types.Set     `tfsdk:"rsync_keys"`
+	RsyncKeyNames       types.Set     `tfsdk:"rsync_key_names"`
+	RsyncUrl            types.String  `tfsdk:"rsync_url"`
+	Server              types.Object  `tfsdk:"server"`
+	SftpEnabled         types.Bool    `tfsdk:"sftp_enabled"`
+	SftpUrl             types.String  `tfsdk:"sftp_url"`
+	SshHost             types.String  `tfsdk:"ssh_host"`
+	SshPort             types.Int64   `tfsdk:"ssh_port"`
+	SshUser             types.String  `tfsdk:"ssh_user"`
+	Timeouts            types.Object  `tfsdk:"timeouts"`
+	WaitForReady        types.Bool    `tfsdk:"wait_for_ready"`
+}
+
+// BorgRepoDataSourceModel is BorgRepoModel without the attributes which only
+// the resource has.
+type BorgRepoDataSourceModel struct {
+	AlertDays           types.Int64   `tfsdk:"alert_days"`
+	AppendOnly          types.Bool    `tfsdk:"append_only"`
+	AppendOnlyKeys      types.Set     `tfsdk:"append_only_keys"`
+	AppendOnlyKeyNames  types.Set     `tfsdk:"append_only_key_names"`
+	BorgRepoUrl         types.String  `tfsdk:"borg_repo_url"`
+	BorgVersion         types.String  `tfsdk:"borg_version"`
+	Compaction          types.Object  `tfsdk:"compaction"`
+	CreatedAt           types.String  `tfsdk:"created_at"`
+	CurrentUsage        types.Float64 `tfsdk:"current_usage"`
+	CurrentUsageBytes   types.Int64   `tfsdk:"current_usage_bytes"`
+	CurrentUsagePercent types.Float64 `tfsdk:"current_usage_percent"`
+	Encryption          types.String  `tfsdk:"encryption"`
+	Format              types.String  `tfsdk:"format"`
+	FullAccessKeys      types.Set     `tfsdk:"full_access_keys"`
+	FullAccessKeyNames  types.Set     `tfsdk:"full_access_key_names"`
+	Id                  types.String  `tfsdk:"id"`
+	LastModified        types.String  `tfsdk:"last_modified"`
+	Name                types.String  `tfsdk:"name"`
+	Quota               types.Int64   `tfsdk:"quota"`
+	QuotaEnabled        types.Bool    `tfsdk:"quota_enabled"`
+	QuotaSize           types.String  `tfsdk:"quota_size"`
+	Region              types.String  `tfsdk:"region"`
+	RepoPath            types.String  `tfsdk:"repo_path"`
+	RsyncKeys           types.Set     `tfsdk:"rsync_keys"`
+	RsyncKeyNames       types.Set     `tfsdk:"rsync_key_names"`
+	RsyncUrl            types.String  `tfsdk:"rsync_url"`
+	Server              types.Object  `tfsdk:"server"`
+	SftpEnabled         types.Bool    `tfsdk:"sftp_enabled"`
+	SftpUrl             types.String  `tfsdk:"sftp_url"`
+	SshHost             types.String  `tfsdk:"ssh_host"`
+	SshPort             types.Int64   `tfsdk:"ssh_port"`
+	SshUser             types.String  `tfsdk:"ssh_user"`
+}
+
+func (m *BorgRepoModel) dataSourceModel() BorgRepoDataSourceModel {
+	return BorgRepoDataSourceModel{
+		AlertDays:           m.AlertDays,
+		AppendOnly:          m.AppendOnly,
+		AppendOnlyKeys:      m.AppendOnlyKeys,
+		AppendOnlyKeyNames:  m.AppendOnlyKeyNames,
+		BorgRepoUrl:         m.BorgRepoUrl,
+		BorgVersion:         m.BorgVersion,
+		Compaction:          m.Compaction,
+		CreatedAt:           m.CreatedAt,
+		CurrentUsage:        m.CurrentUsage,
+		CurrentUsageBytes:   m.CurrentUsageBytes,
+		CurrentUsagePercent: m.CurrentUsagePercent,
+		Encryption:          m.Encryption,
+		Format:              m.Format,
+		FullAccessKeys:      m.FullAccessKeys,
+		FullAccessKeyNames:  m.FullAccessKeyNames,
+		Id:                  m.Id,
+		LastModified:        m.LastModified,
+		Name:                m.Name,
+		Quota:               m.Quota,
+		QuotaEnabled:        m.QuotaEnabled,
+		QuotaSize:           m.QuotaSize,
+		Region:              m.Region,
+		RepoPath:            m.RepoPath,
+		RsyncKeys:           m.RsyncKeys,
+		RsyncKeyNames:       m.RsyncKeyNames,
+		RsyncUrl:            m.RsyncUrl,
+		Server:              m.Server,
+		SftpEnabled:         m.SftpEnabled,
+		SftpUrl:             m.SftpUrl,
+		SshHost:             m.SshHost,
+		SshPort:             m.SshPort,
+		SshUser:             m.SshUser,
+	}
 }
 
 func (m *BorgRepoModel) update(
@@ -39,7 +131,7 @@ func (m *BorgRepoModel) update(
 
 	m.AlertDays = types.Int64Value(int64(repo.AlertDays))
 	m.AppendOnly = types.BoolValue(repo.AppendOnly)
-	m.AppendOnlyKeys, diagnostics = types.ListValueFrom(
+	m.AppendOnlyKeys, diagnostics = types.SetValueFrom(
 		ctx,
 		types.StringType,
 		repo.AppendOnlyKeys,
@@ -48,14 +140,27 @@ func (m *BorgRepoModel) update(
 		return diagnostics
 	}
 
-	m.BorgVersion = types.StringValue(string(repo.BorgVersion))
+	// Keep the configured spelling of values which BorgBase normalises, so
+	// that they don't show up as a diff on every plan.
+	if !strings.EqualFold(m.BorgVersion.ValueString(), repo.BorgVersion) {
+		m.BorgVersion = types.StringValue(repo.BorgVersion)
+	}
+
+	hourTimezone := types.StringValue(repo.CompactionHourTimezone)
+	if !m.Compaction.IsNull() && !m.Compaction.IsUnknown() {
+		prior, ok := m.Compaction.Attributes()["hour_timezone"].(types.String)
+		if ok && equalTimezones(prior.ValueString(), repo.CompactionHourTimezone) {
+			hourTimezone = prior
+		}
+	}
+
 	m.Compaction, diagnostics = types.ObjectValueFrom(
 		ctx,
 		compactionAttributes,
 		CompactionModel{
 			Enabled:      types.BoolValue(repo.CompactionEnabled),
 			Hour:         types.Int64Value(int64(repo.CompactionHour)),
-			HourTimezone: types.StringValue(repo.CompactionHourTimezone),
+			HourTimezone: hourTimezone,
 			Interval:     types.Int64Value(int64(repo.CompactionInterval)),
 			IntervalUnit: types.StringValue(repo.CompactionIntervalUnit),
 		},
@@ -66,9 +171,17 @@ func (m *BorgRepoModel) update(
 
 	m.CreatedAt = types.StringValue(repo.CreatedAt)
 	m.CurrentUsage = types.Float64Value(repo.CurrentUsage)
+	m.CurrentUsageBytes = types.Int64Value(
+		int64(math.Round(repo.CurrentUsage * bytesPerMegabyte)),
+	)
+	var usagePercent float64
+	if repo.Quota > 0 {
+		usagePercent = repo.CurrentUsage / float64(repo.Quota) * 100
+	}
+	m.CurrentUsagePercent = types.Float64Value(usagePercent)
 	m.Encryption = types.StringValue(repo.Encryption)
 	m.Format = types.StringValue(repo.Format)
-	m.FullAccessKeys, diagnostics = types.ListValueFrom(
+	m.FullAccessKeys, diagnostics = types.SetValueFrom(
 		ctx,
 		types.StringType,
 		repo.FullAccessKeys,
@@ -82,9 +195,14 @@ func (m *BorgRepoModel) update(
 	m.Name = types.StringValue(repo.Name)
 	m.Quota = types.Int64Value(int64(repo.Quota))
 	m.QuotaEnabled = types.BoolValue(repo.QuotaEnabled)
+	// Keep the configured quota size unless it no longer matches the quota.
+	if size, err := parseSize(m.QuotaSize.ValueString()); m.QuotaSize.IsNull() ||
+		m.QuotaSize.IsUnknown() || err != nil || size != int64(repo.Quota) {
+		m.QuotaSize = types.StringValue(formatSize(int64(repo.Quota)))
+	}
 	m.Region = types.StringValue(repo.Region)
 	m.RepoPath = types.StringValue(repo.RepoPath)
-	m.RsyncKeys, diagnostics = types.ListValueFrom(
+	m.RsyncKeys, diagnostics = types.SetValueFrom(
 		ctx,
 		types.StringType,
 		repo.RsyncKeys,
@@ -112,9 +230,55 @@ func (m *BorgRepoModel) update(
 	}
 
 	m.SftpEnabled = types.BoolValue(repo.SftpEnabled)
+	m.setConnectionAttributes(repo)
 	return diagnostics
 }
 
+// setConnectionAttributes sets the attributes derived from the repo path.
+// They are null until the repo has been assigned a path.
+func (m *BorgRepoModel) setConnectionAttributes(repo BorgRepoPayload) {
+	m.BorgRepoUrl = types.StringNull()
+	m.RsyncUrl = types.StringNull()
+	m.SftpUrl = types.StringNull()
+	m.SshHost = types.StringNull()
+	m.SshPort = types.Int64Null()
+	m.SshUser = types.StringNull()
+
+	parsed, err := parseRepoPath(repo.RepoPath)
+	if err != nil {
+		return
+	}
+
+	m.BorgRepoUrl = types.StringValue(parsed.borgUrl())
+	m.RsyncUrl = types.StringValue(parsed.rsyncUrl())
+	if repo.SftpEnabled {
+		m.SftpUrl = types.StringValue(parsed.sftpUrl())
+	}
+	m.SshHost = types.StringValue(parsed.Host)
+	m.SshPort = types.Int64Value(parsed.Port)
+	m.SshUser = types.StringValue(parsed.User)
+}
+
+//go:generate go run timezones_gen.go
+
+// canonicalTimezone returns the lowercase name which represents every link to
+// the timezone with the given name.
+func canonicalTimezone(name string) string {
+	name = strings.ToLower(name)
+	if zone, ok := timezoneLinks[name]; ok {
+		return zone
+	}
+	return name
+}
+
+// equalTimezones returns whether two timezone names refer to the same
+// timezone, e.g. "UTC" and "Etc/UTC" or "US/Eastern" and "America/New_York".
+// Zones which merely share their offsets,
+// such as "Europe/Berlin" and "Europe/Paris", are different timezones.
+func equalTimezones(a, b string) bool {
+	return canonicalTimezone(a) == canonicalTimezone(b)
+}
+
 type CompactionModel struct {
 	Enabled      types.Bool   `tfsdk:"enabled"`
 	Hour         types.Int64  `tfsdk:"hour"`
@@ -191,6 +355,16 @@ type BorgRepoPayload struct {
 	CurrentUsage           float64  `json:"currentUsage"`
 }
 
+// ready returns whether the repo has been assigned to a server and can be
+// accessed over SSH.
+func (p BorgRepoPayload) ready() bool {
+	return p.RepoPath != "" &&
+		p.Server.Hostname != "" &&
+		p.Server.FingerprintEcdsa != "" &&
+		p.Server.FingerprintEd25519 != "" &&
+		p.Server.FingerprintRsa != ""
+}
+
 type BorgReposPayload []BorgRepoPayload
 
 type BorgRepoAddPayload struct {
@@ -0,0 +1,203 @@
+package provider
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestEqualTimezones(t *testing.T) {
+	for _, tc := range []struct {
+		a, b  string
+		equal bool
+	}{
+		{"UTC", "UTC", true},
+		{"utc", "UTC", true},
+		{"Etc/UTC", "UTC", true},
+		{"Europe/Berlin", "europe/berlin", true},
+		{"Etc/Zulu", "utc", true},
+		{"US/Eastern", "America/New_York", true},
+		{"Asia/Calcutta", "Asia/Kolkata", true},
+		{"GMT", "Etc/GMT", true},
+		{"Arctic/Longyearbyen", "Europe/Berlin", true},
+		{"Europe/Berlin", "Europe/Paris", false},
+		{"Europe/Berlin", "UTC", false},
+		{"America/New_York", "America/Chicago", false},
+		{"GMT", "UTC", false},
+		{"Not/AZone", "UTC", false},
+	} {
+		if actual := equalTimezones(tc.a, tc.b); actual != tc.equal {
+			t.Errorf("equalTimezones(%q, %q): expected %t, got %t",
+				tc.a, tc.b, tc.equal, actual)
+		}
+	}
+}
+
+func TestBorgRepoModelUpdate_normalisedValues(t *testing.T) {
+	ctx := context.Background()
+
+	data := testNullBorgRepoModel()
+	data.BorgVersion = types.StringValue("latest")
+	data.Compaction = testCompaction(types.BoolValue(true))
+
+	repo := BorgRepoPayload{
+		BorgVersion:            "LATEST",
+		CompactionEnabled:      true,
+		CompactionHour:         14,
+		CompactionHourTimezone: "europe/berlin",
+		CompactionInterval:     6,
+		CompactionIntervalUnit: "weeks",
+	}
+	if diagnostics := data.update(ctx, repo); diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+
+	if data.BorgVersion.ValueString() != "latest" {
+		t.Errorf("borg_version: expected latest, got %s", data.BorgVersion)
+	}
+	timezone := data.Compaction.Attributes()["hour_timezone"].(types.String)
+	if timezone.ValueString() != "Europe/Berlin" {
+		t.Errorf("compaction.hour_timezone: expected Europe/Berlin, got %s", timezone)
+	}
+
+	repo.BorgVersion = "1.2"
+	repo.CompactionHourTimezone = "UTC"
+	if diagnostics := data.update(ctx, repo); diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+
+	if data.BorgVersion.ValueString() != "1.2" {
+		t.Errorf("borg_version: expected 1.2, got %s", data.BorgVersion)
+	}
+	timezone = data.Compaction.Attributes()["hour_timezone"].(types.String)
+	if timezone.ValueString() != "UTC" {
+		t.Errorf("compaction.hour_timezone: expected UTC, got %s", timezone)
+	}
+}
+
+func TestBorgRepoModelUpdate_quota(t *testing.T) {
+	ctx := context.Background()
+
+	for name, tc := range map[string]struct {
+		quotaSize       types.String
+		quota           int
+		usage           float64
+		expectedSize    string
+		expectedBytes   int64
+		expectedPercent float64
+	}{
+		"no quota": {
+			quotaSize:     types.StringNull(),
+			usage:         1.5,
+			expectedSize:  "0MiB",
+			expectedBytes: 1572864,
+		},
+		"configured size kept": {
+			quotaSize:       types.StringValue("500GB"),
+			quota:           476837,
+			usage:           47683.7,
+			expectedSize:    "500GB",
+			expectedBytes:   49999983411,
+			expectedPercent: 10,
+		},
+		"configured size replaced": {
+			quotaSize:       types.StringValue("500GB"),
+			quota:           1024,
+			usage:           256,
+			expectedSize:    "1GiB",
+			expectedBytes:   268435456,
+			expectedPercent: 25,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data := testNullBorgRepoModel()
+			data.QuotaSize = tc.quotaSize
+
+			repo := BorgRepoPayload{Quota: tc.quota, CurrentUsage: tc.usage}
+			if diagnostics := data.update(ctx, repo); diagnostics.HasError() {
+				t.Fatal(diagnostics)
+			}
+
+			if data.QuotaSize.ValueString() != tc.expectedSize {
+				t.Errorf("quota_size: expected %s, got %s", tc.expectedSize, data.QuotaSize)
+			}
+			if data.CurrentUsageBytes.ValueInt64() != tc.expectedBytes {
+				t.Errorf("current_usage_bytes: expected %d, got %s",
+					tc.expectedBytes, data.CurrentUsageBytes)
+			}
+			if percent := data.CurrentUsagePercent.ValueFloat64(); math.Abs(percent-tc.expectedPercent) > 0.001 {
+				t.Errorf("current_usage_percent: expected %f, got %f",
+					tc.expectedPercent, percent)
+			}
+		})
+	}
+}
+
+func TestBorgRepoModelUpdate_connectionAttributes(t *testing.T) {
+	ctx := context.Background()
+
+	for name, tc := range map[string]struct {
+		repoPath    string
+		sftpEnabled bool
+		expected    map[string]attr.Value
+	}{
+		"not ready": {
+			expected: map[string]attr.Value{
+				"borg_repo_url": types.StringNull(),
+				"rsync_url":     types.StringNull(),
+				"sftp_url":      types.StringNull(),
+				"ssh_host":      types.StringNull(),
+				"ssh_port":      types.Int64Null(),
+				"ssh_user":      types.StringNull(),
+			},
+		},
+		"sftp disabled": {
+			repoPath: "ssh://abc123@abc123.repo.borgbase.com/./repo",
+			expected: map[string]attr.Value{
+				"borg_repo_url": types.StringValue("ssh://abc123@abc123.repo.borgbase.com/./repo"),
+				"rsync_url":     types.StringValue("abc123@abc123.repo.borgbase.com:repo"),
+				"sftp_url":      types.StringNull(),
+				"ssh_host":      types.StringValue("abc123.repo.borgbase.com"),
+				"ssh_port":      types.Int64Value(22),
+				"ssh_user":      types.StringValue("abc123"),
+			},
+		},
+		"sftp enabled with port": {
+			repoPath:    "ssh://abc123@abc123.repo.borgbase.com:2222/./repo",
+			sftpEnabled: true,
+			expected: map[string]attr.Value{
+				"borg_repo_url": types.StringValue("ssh://abc123@abc123.repo.borgbase.com:2222/./repo"),
+				"rsync_url":     types.StringValue("abc123@abc123.repo.borgbase.com:repo"),
+				"sftp_url":      types.StringValue("sftp://abc123@abc123.repo.borgbase.com:2222/./repo"),
+				"ssh_host":      types.StringValue("abc123.repo.borgbase.com"),
+				"ssh_port":      types.Int64Value(2222),
+				"ssh_user":      types.StringValue("abc123"),
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data := testNullBorgRepoModel()
+			repo := BorgRepoPayload{RepoPath: tc.repoPath, SftpEnabled: tc.sftpEnabled}
+			if diagnostics := data.update(ctx, repo); diagnostics.HasError() {
+				t.Fatal(diagnostics)
+			}
+
+			actual := map[string]attr.Value{
+				"borg_repo_url": data.BorgRepoUrl,
+				"rsync_url":     data.RsyncUrl,
+				"sftp_url":      data.SftpUrl,
+				"ssh_host":      data.SshHost,
+				"ssh_port":      data.SshPort,
+				"ssh_user":      data.SshUser,
+			}
+			for name, expected := range tc.expected {
+				if !actual[name].Equal(expected) {
+					t.Errorf("%s: expected %s, got %s", name, expected, actual[name])
+				}
+			}
+		})
+	}
+}
@@ -3,36 +3,43 @@ package provider
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/gjabell/terraform-provider-borgbase/gql"
 	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
-	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
-	"github.com/hashicorp/terraform-plugin-framework/resource/schema/float64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
-	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/setplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces
 var _ resource.Resource = &BorgRepoResource{}
 var _ resource.ResourceWithImportState = &BorgRepoResource{}
+var _ resource.ResourceWithModifyPlan = &BorgRepoResource{}
+var _ resource.ResourceWithValidateConfig = &BorgRepoResource{}
 
 func NewBorgRepoResource() resource.Resource {
 	return &BorgRepoResource{}
 }
 
 type BorgRepoResource struct {
-	client *gql.Client
+	client       *gql.Client
+	repoDefaults RepoDefaultsModel
+	borgVersions *borgVersionCache
 }
 
 func (r *BorgRepoResource) Metadata(
@@ -50,11 +57,12 @@ func (r *BorgRepoResource) Schema(
 ) {
 	resp.Schema = schema.Schema{
 		MarkdownDescription: "BorgBase borg repository.",
+		Version:             1,
 		Attributes: map[string]schema.Attribute{
 			"alert_days": schema.Int64Attribute{
 				Computed:            true,
 				Optional:            true,
-				MarkdownDescription: "Number of days after which an alert should be triggered if no new backups are made.",
+				MarkdownDescription: "Number of days after which an alert should be triggered if no new backups are made. Inherited from the provider's `repo_defaults` block if unset.",
 				PlanModifiers: []planmodifier.Int64{
 					int64planmodifier.UseStateForUnknown(),
 				},
@@ -67,24 +75,48 @@ func (r *BorgRepoResource) Schema(
 					boolplanmodifier.UseStateForUnknown(),
 				},
 			},
-			"append_only_keys": schema.ListAttribute{
+			"append_only_keys": schema.SetAttribute{
 				ElementType:         types.StringType,
 				Computed:            true,
 				Optional:            true,
 				MarkdownDescription: "IDs of SSH keys which are only allowed to append data to the repository.",
-				PlanModifiers: []planmodifier.List{
-					listplanmodifier.UseStateForUnknown(),
+				PlanModifiers: []planmodifier.Set{
+					setplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"append_only_key_names": schema.SetAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "Names of SSH keys which are only allowed to append data to the repository. Keys which don't exist yet, such as keys created in the same apply, are resolved when applying. Names shared by several keys are rejected. Conflicts with `append_only_keys`.",
+				Validators: []validator.Set{
+					setvalidator.ConflictsWith(path.MatchRoot("append_only_keys")),
+				},
+			},
+			"borg_repo_url": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "URL of the repository for borg, e.g. `ssh://abc123@abc123.repo.borgbase.com/./repo`.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
 			"borg_version": schema.StringAttribute{
 				Computed:            true,
 				Optional:            true,
 				MarkdownDescription: "Borg version to use for the repository (defaults to latest stable version).",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				Validators: []validator.String{
+					borgVersionValidator{},
+				},
 			},
 			"compaction": schema.SingleNestedAttribute{
 				Computed:            true,
 				Optional:            true,
-				MarkdownDescription: "Settings for repository compaction.",
+				MarkdownDescription: "Settings for repository compaction. Inherited from the provider's `repo_defaults` block if unset.",
+				PlanModifiers: []planmodifier.Object{
+					objectplanmodifier.UseStateForUnknown(),
+				},
 				Attributes: map[string]schema.Attribute{
 					"enabled": schema.BoolAttribute{
 						Required:            true,
@@ -105,10 +137,13 @@ func (r *BorgRepoResource) Schema(
 					},
 					"hour_timezone": schema.StringAttribute{
 						Required:            true,
-						MarkdownDescription: "Timezone of repository compaction hour.",
+						MarkdownDescription: "Timezone of repository compaction hour, as an IANA timezone name such as `Europe/Berlin`.",
 						PlanModifiers: []planmodifier.String{
 							stringplanmodifier.UseStateForUnknown(),
 						},
+						Validators: []validator.String{
+							timezoneValidator{},
+						},
 					},
 					"interval": schema.Int64Attribute{
 						Required:            true,
@@ -139,12 +174,25 @@ func (r *BorgRepoResource) Schema(
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
+			// current_usage and last_modified may change on every update, so
+			// they can't reuse the prior state value.
 			"current_usage": schema.Float64Attribute{
 				Computed:            true,
 				MarkdownDescription: "Current usage of the repository in megabytes.",
-				PlanModifiers: []planmodifier.Float64{
-					float64planmodifier.UseStateForUnknown(),
-				},
+			},
+			"current_usage_bytes": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Current usage of the repository in bytes.",
+			},
+			"current_usage_percent": schema.Float64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Current usage of the repository as a percentage of its quota (0 if there is no quota).",
+			},
+			"deletion_protection": schema.BoolAttribute{
+				Computed:            true,
+				Optional:            true,
+				Default:             booldefault.StaticBool(true),
+				MarkdownDescription: "Whether Terraform is prevented from deleting the repository (defaults to true). Must be set to false and applied before the repository can be destroyed or replaced.",
 			},
 			"encryption": schema.StringAttribute{
 				Computed:            true,
@@ -153,6 +201,12 @@ func (r *BorgRepoResource) Schema(
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
+			"force_destroy": schema.BoolAttribute{
+				Computed:            true,
+				Optional:            true,
+				Default:             booldefault.StaticBool(false),
+				MarkdownDescription: "Whether Terraform may delete the repository while it still contains data (defaults to false). Must be applied before the repository can be destroyed or replaced.",
+			},
 			"format": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "Format of the repository.",
@@ -160,13 +214,21 @@ func (r *BorgRepoResource) Schema(
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
-			"full_access_keys": schema.ListAttribute{
+			"full_access_keys": schema.SetAttribute{
 				ElementType:         types.StringType,
 				Computed:            true,
 				Optional:            true,
 				MarkdownDescription: "IDs of SSH keys which have full access to the repository.",
-				PlanModifiers: []planmodifier.List{
-					listplanmodifier.UseStateForUnknown(),
+				PlanModifiers: []planmodifier.Set{
+					setplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"full_access_key_names": schema.SetAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "Names of SSH keys which have full access to the repository. Keys which don't exist yet, such as keys created in the same apply, are resolved when applying. Names shared by several keys are rejected. Conflicts with `full_access_keys`.",
+				Validators: []validator.Set{
+					setvalidator.ConflictsWith(path.MatchRoot("full_access_keys")),
 				},
 			},
 			"id": schema.StringAttribute{
@@ -179,9 +241,6 @@ func (r *BorgRepoResource) Schema(
 			"last_modified": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "Date when the repository was last modified.",
-				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.UseStateForUnknown(),
-				},
 			},
 			"name": schema.StringAttribute{
 				Required:            true,
@@ -203,10 +262,24 @@ func (r *BorgRepoResource) Schema(
 					boolplanmodifier.UseStateForUnknown(),
 				},
 			},
+			"quota_size": schema.StringAttribute{
+				Computed:            true,
+				Optional:            true,
+				MarkdownDescription: "Max allowed size of the repository with a unit, e.g. `500GB` or `1TiB`. Conflicts with `quota`.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				Validators: []validator.String{
+					sizeValidator{},
+					stringvalidator.ConflictsWith(path.MatchRoot("quota")),
+				},
+			},
 			"region": schema.StringAttribute{
-				Required:            true,
-				MarkdownDescription: "Region where the repository is hosted (eu or us).",
+				Computed:            true,
+				Optional:            true,
+				MarkdownDescription: "Region where the repository is hosted (eu or us). Must be set here or in the provider's `repo_defaults` block.",
 				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
 					stringplanmodifier.RequiresReplace(),
 				},
 				Validators: []validator.String{
@@ -220,18 +293,36 @@ func (r *BorgRepoResource) Schema(
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
-			"rsync_keys": schema.ListAttribute{
+			"rsync_keys": schema.SetAttribute{
 				ElementType:         types.StringType,
 				Computed:            true,
 				Optional:            true,
 				MarkdownDescription: "IDs of SSH keys which can access the repository via rsync.",
-				PlanModifiers: []planmodifier.List{
-					listplanmodifier.UseStateForUnknown(),
+				PlanModifiers: []planmodifier.Set{
+					setplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"rsync_key_names": schema.SetAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "Names of SSH keys which can access the repository via rsync. Keys which don't exist yet, such as keys created in the same apply, are resolved when applying. Names shared by several keys are rejected. Conflicts with `rsync_keys`.",
+				Validators: []validator.Set{
+					setvalidator.ConflictsWith(path.MatchRoot("rsync_keys")),
+				},
+			},
+			"rsync_url": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Location of the repository for rsync over SSH, e.g. `abc123@abc123.repo.borgbase.com:repo`. The port is not included, see `ssh_port`.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
 			"server": schema.SingleNestedAttribute{
 				Computed:            true,
 				MarkdownDescription: "Information about the server where the repository is hosted.",
+				PlanModifiers: []planmodifier.Object{
+					objectplanmodifier.UseStateForUnknown(),
+				},
 				Attributes: map[string]schema.Attribute{
 					"fingerprint_ecdsa": schema.StringAttribute{
 						Computed:            true,
@@ -275,6 +366,40 @@ func (r *BorgRepoResource) Schema(
 					boolplanmodifier.UseStateForUnknown(),
 				},
 			},
+			"sftp_url": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "URL of the repository for SFTP clients (null if SFTP is disabled).",
+			},
+			"ssh_host": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Hostname of the repository server.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"ssh_port": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "SSH port of the repository server.",
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+			},
+			"ssh_user": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "SSH user for accessing the repository.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"wait_for_ready": schema.BoolAttribute{
+				Computed:            true,
+				Optional:            true,
+				Default:             booldefault.StaticBool(true),
+				MarkdownDescription: "Whether to wait after creating the repository until it has been assigned to a server and `repo_path` and the `server` fingerprints are available (defaults to true). Waiting is bounded by the create timeout. A repository which times out is tainted and can be replaced despite `deletion_protection`.",
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeoutsBlock(),
 		},
 	}
 }
@@ -288,89 +413,170 @@ func (r *BorgRepoResource) Configure(
 		return
 	}
 
-	client, ok := req.ProviderData.(*gql.Client)
+	data, ok := req.ProviderData.(*BorgBaseResourceData)
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
 			fmt.Sprintf(
-				"Expected *gql.Client, got: %T. "+
+				"Expected *provider.BorgBaseResourceData, got: %T. "+
 					"Please report this issue to the provider developers.",
 				req.ProviderData),
 		)
 		return
 	}
-	r.client = client
+	r.client = data.Client
+	r.repoDefaults = data.RepoDefaults
+	r.borgVersions = data.borgVersions
 }
 
-func setArguments(
+func (r *BorgRepoResource) ValidateConfig(
 	ctx context.Context,
-	args map[string]gql.Argument,
-	data BorgRepoModel,
-) diag.Diagnostics {
-	var diagnostics diag.Diagnostics
-
-	for name, attr := range map[string]basetypes.Int64Value{
-		"alertDays": data.AlertDays,
-		"quota":     data.Quota,
-	} {
-		if !attr.IsNull() || !attr.IsUnknown() {
-			args[name] = gql.Optional(attr.ValueInt64())
-		}
+	req resource.ValidateConfigRequest,
+	resp *resource.ValidateConfigResponse,
+) {
+	var data BorgRepoModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	for name, attr := range map[string]basetypes.BoolValue{
-		"appendOnly":   data.AppendOnly,
-		"sftpEnabled":  data.SftpEnabled,
-		"quotaEnabled": data.QuotaEnabled,
+	var ids, names []keyAccessSet
+	for _, list := range data.keyAccessLists() {
+		ids = append(ids, keyAccessSet{attribute: list.idAttribute, keys: *list.ids})
+		names = append(names, keyAccessSet{attribute: list.nameAttribute, keys: *list.names})
+	}
+	checkKeyOverlap(ids, &resp.Diagnostics)
+	checkKeyOverlap(names, &resp.Diagnostics)
+
+	for name, keys := range map[string]types.Set{
+		"append_only_keys":      data.AppendOnlyKeys,
+		"append_only_key_names": data.AppendOnlyKeyNames,
 	} {
-		if !attr.IsNull() || !attr.IsUnknown() {
-			args[name] = gql.Optional(attr.ValueBool())
+		if !keys.IsNull() && !keys.IsUnknown() && len(keys.Elements()) > 0 &&
+			!data.AppendOnly.IsUnknown() && !data.AppendOnly.ValueBool() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(name),
+				"Invalid append-only keys",
+				fmt.Sprintf("%s can only be set if append_only is true.", name),
+			)
 		}
 	}
 
-	if !data.BorgVersion.IsNull() && !data.BorgVersion.IsUnknown() {
-		args["borgVersion"] = gql.Optional(data.BorgVersion.ValueString())
+	if data.QuotaEnabled.ValueBool() && data.Quota.IsNull() &&
+		data.QuotaSize.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("quota"),
+			"Missing quota",
+			"quota or quota_size must be set if quota_enabled is true.",
+		)
 	}
+}
 
-	if !data.Compaction.IsNull() && !data.Compaction.IsUnknown() {
-		var compaction CompactionModel
-		diagnostics = data.Compaction.As(
-			ctx,
-			&compaction,
-			basetypes.ObjectAsOptions{},
+func (r *BorgRepoResource) ModifyPlan(
+	ctx context.Context,
+	req resource.ModifyPlanRequest,
+	resp *resource.ModifyPlanResponse,
+) {
+	// Nothing to do when the resource is being destroyed.
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var data BorgRepoModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var config BorgRepoModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.applyRepoDefaults(config, r.repoDefaults)
+	if data.Region.IsUnknown() && config.Region.IsNull() && r.repoDefaults.Region.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("region"),
+			"Missing region",
+			"region must be set in the resource or in the provider's repo_defaults block.",
 		)
-		if diagnostics.HasError() {
-			return diagnostics
+		return
+	}
+
+	// Key names can only be resolved once the provider has been configured.
+	if r.client != nil {
+		resp.Diagnostics.Append(data.resolveKeyNames(ctx, r.client, true)...)
+		if resp.Diagnostics.HasError() {
+			return
 		}
-		args["compactionEnabled"] = gql.Optional(compaction.Enabled.ValueBool())
-		args["compactionHour"] = gql.Optional(compaction.Hour.ValueInt64())
-		args["compactionHourTimezone"] = gql.Optional(
-			compaction.HourTimezone.ValueString(),
-		)
-		args["compactionInterval"] = gql.Optional(compaction.Interval.ValueInt64())
-		args["compactionIntervalUnit"] = gql.Optional(
-			compaction.IntervalUnit.ValueString(),
-		)
 	}
 
-	for name, attr := range map[string]basetypes.ListValue{
-		"appendOnlyKeys": data.AppendOnlyKeys,
-		"fullAccessKeys": data.FullAccessKeys,
-		"rsyncKeys":      data.RsyncKeys,
-	} {
-		if attr.IsNull() || attr.IsUnknown() {
-			continue
+	// Versions released after this provider are only known to the API.
+	var borgVersion types.String
+	resp.Diagnostics.Append(
+		req.Config.GetAttribute(ctx, path.Root("borg_version"), &borgVersion)...,
+	)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if r.client != nil && !borgVersion.IsNull() && !borgVersion.IsUnknown() &&
+		!isKnownBorgVersion(borgVersion.ValueString(), knownBorgVersions) {
+		versions, err := r.borgVersions.list(ctx, r.client)
+		if err != nil {
+			addClientError(&resp.Diagnostics, "Failed to list borg versions", err)
+			return
+		}
+		if !isKnownBorgVersion(borgVersion.ValueString(), versions) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("borg_version"),
+				"Invalid borg version",
+				fmt.Sprintf("%q is not a borg version offered by BorgBase (%s).%s",
+					borgVersion.ValueString(), strings.Join(versions, ", "),
+					didYouMean(suggest(borgVersion.ValueString(), versions))),
+			)
+			return
 		}
+	}
 
-		var keys []string
-		diagnostics = attr.ElementsAs(ctx, &keys, false)
-		if diagnostics.HasError() {
-			return diagnostics
+	// quota and quota_size describe the same value, so derive whichever one
+	// wasn't configured from the other.
+	var quota types.Int64
+	var quotaSize types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("quota"), &quota)...)
+	resp.Diagnostics.Append(
+		req.Config.GetAttribute(ctx, path.Root("quota_size"), &quotaSize)...,
+	)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	switch {
+	case quotaSize.IsUnknown():
+		data.Quota = types.Int64Unknown()
+	case !quotaSize.IsNull():
+		size, err := parseSize(quotaSize.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("quota_size"),
+				"Invalid size",
+				err.Error(),
+			)
+			return
+		}
+		data.Quota = types.Int64Value(size)
+	case quota.IsUnknown():
+		data.QuotaSize = types.StringUnknown()
+	case !quota.IsNull():
+		if size, err := parseSize(data.QuotaSize.ValueString()); err != nil ||
+			size != quota.ValueInt64() {
+			data.QuotaSize = types.StringValue(formatSize(quota.ValueInt64()))
 		}
-		args[name] = gql.Optional(keys)
 	}
 
-	return diagnostics
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &data)...)
 }
 
 func (r *BorgRepoResource) Create(
@@ -385,6 +591,20 @@ func (r *BorgRepoResource) Create(
 		return
 	}
 
+	ctx, cancel, diagnostics := withTimeout(ctx, data.Timeouts, "create")
+	resp.Diagnostics.Append(diagnostics...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	defer cancel()
+
+	if data.hasUnresolvedKeyNames() {
+		resp.Diagnostics.Append(data.resolveKeyNames(ctx, r.client, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
 	args := gql.Arguments{
 		"name":   gql.Required(data.Name.ValueString()),
 		"region": gql.Required(data.Region.ValueString()),
@@ -395,8 +615,8 @@ func (r *BorgRepoResource) Create(
 	}
 
 	var payload BorgRepoAddPayload
-	if err := r.client.Mutation("repoAdd", &payload, args); err != nil {
-		resp.Diagnostics.AddError("Failed to create borg repo", err.Error())
+	if err := r.client.Mutation(ctx, "repoAdd", &payload, args); err != nil {
+		addClientError(&resp.Diagnostics, "Failed to create borg repo", err)
 		return
 	}
 	resp.Diagnostics.Append(data.update(ctx, payload.RepoAdded)...)
@@ -406,9 +626,64 @@ func (r *BorgRepoResource) Create(
 		"name": data.Name,
 	})
 
+	if data.WaitForReady.ValueBool() && !payload.RepoAdded.ready() {
+		repo, err := waitForRepo(ctx, r.client, payload.RepoAdded.Id)
+		if err != nil {
+			// Save the repo so that it's tainted rather than left behind.
+			// It was never ready to be used, so it isn't protected from
+			// being deleted when the tainted repo is replaced.
+			data.DeletionProtection = types.BoolValue(false)
+			resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+			addClientError(&resp.Diagnostics, "Borg repo didn't become ready", err)
+			return
+		}
+		resp.Diagnostics.Append(data.update(ctx, *repo)...)
+	}
+
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+// Newly created repos are polled with exponential backoff until they're ready.
+var (
+	repoReadyInitialDelay = time.Second
+	repoReadyMaxDelay     = 15 * time.Second
+)
+
+// waitForRepo polls the repo until it's ready or ctx is done.
+func waitForRepo(
+	ctx context.Context,
+	client *gql.Client,
+	id string,
+) (*BorgRepoPayload, error) {
+	delay := repoReadyInitialDelay
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for repo %s: %w", id, ctx.Err())
+		case <-time.After(delay):
+		}
+
+		repo, err := findRepo(ctx, client, id)
+		if err != nil {
+			return nil, err
+		}
+		if repo == nil {
+			return nil, fmt.Errorf("repo %s no longer exists", id)
+		}
+		if repo.ready() {
+			return repo, nil
+		}
+
+		tflog.Debug(ctx, "waiting for repo to become ready", map[string]interface{}{
+			"id":    id,
+			"delay": delay.String(),
+		})
+		if delay *= 2; delay > repoReadyMaxDelay {
+			delay = repoReadyMaxDelay
+		}
+	}
+}
+
 func (r *BorgRepoResource) Read(
 	ctx context.Context,
 	req resource.ReadRequest,
@@ -421,10 +696,17 @@ func (r *BorgRepoResource) Read(
 		return
 	}
 
+	ctx, cancel, diagnostics := withTimeout(ctx, data.Timeouts, "read")
+	resp.Diagnostics.Append(diagnostics...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	defer cancel()
+
 	var payload BorgReposPayload
 	args := gql.Arguments{"name": gql.Optional(data.Name.ValueString())}
-	if err := r.client.Query("repoList", &payload, args); err != nil {
-		resp.Diagnostics.AddError("Failed to read borg repo", err.Error())
+	if err := r.client.Query(ctx, "repoList", &payload, args); err != nil {
+		addClientError(&resp.Diagnostics, "Failed to read borg repo", err)
 		return
 	}
 
@@ -454,25 +736,54 @@ func (r *BorgRepoResource) Update(
 	req resource.UpdateRequest,
 	resp *resource.UpdateResponse,
 ) {
-	var data BorgRepoModel
+	var data, state BorgRepoModel
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	args := gql.Arguments{
-		"id":   gql.Required(data.Id.ValueString()),
-		"name": gql.Optional(data.Name.ValueString()),
+	ctx, cancel, diagnostics := withTimeout(ctx, data.Timeouts, "update")
+	resp.Diagnostics.Append(diagnostics...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
-	if diagnostics := setArguments(ctx, args, data); diagnostics.HasError() {
-		resp.Diagnostics.Append(diagnostics...)
+	defer cancel()
+
+	if data.hasUnresolvedKeyNames() {
+		resp.Diagnostics.Append(data.resolveKeyNames(ctx, r.client, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	args := gql.Arguments{"id": gql.Required(state.Id.ValueString())}
+	resp.Diagnostics.Append(setChangedArguments(ctx, args, data, state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Only attributes which BorgBase doesn't store have changed, so the repo
+	// is left as it is.
+	if len(args) == 1 {
+		raw, err := knownFromState(req.Plan.Raw, req.State.Raw)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to update borg repo", err.Error())
+			return
+		}
+		resp.State.Raw = raw
+
+		tflog.Trace(ctx, "updated repo without changes in BorgBase", map[string]interface{}{
+			"id":   data.Id,
+			"name": data.Name,
+		})
 		return
 	}
 
 	var payload BorgRepoEditPayload
-	if err := r.client.Mutation("repoEdit", &payload, args); err != nil {
-		resp.Diagnostics.AddError("Failed to update borg repo", err.Error())
+	if err := r.client.Mutation(ctx, "repoEdit", &payload, args); err != nil {
+		addClientError(&resp.Diagnostics, "Failed to update borg repo", err)
 		return
 	}
 	resp.Diagnostics.Append(data.update(ctx, payload.RepoEdited)...)
@@ -497,9 +808,52 @@ func (r *BorgRepoResource) Delete(
 		return
 	}
 
+	ctx, cancel, diagnostics := withTimeout(ctx, data.Timeouts, "delete")
+	resp.Diagnostics.Append(diagnostics...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	defer cancel()
+
+	// State written before these attributes existed has no value for them,
+	// so fall back to their defaults.
+	if data.DeletionProtection.IsNull() || data.DeletionProtection.ValueBool() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("deletion_protection"),
+			"Deletion protection enabled",
+			fmt.Sprintf("Borg repo %q (%s) is protected from deletion. Set "+
+				"deletion_protection to false and apply the change before "+
+				"destroying or replacing it.", data.Name.ValueString(), data.Id.ValueString()),
+		)
+		return
+	}
+
+	if !data.ForceDestroy.ValueBool() {
+		// The usage in state may be stale, so check the current usage.
+		repo, err := findRepo(ctx, r.client, data.Id.ValueString())
+		if err != nil {
+			addClientError(&resp.Diagnostics, "Failed to read borg repo", err)
+			return
+		}
+		if repo == nil {
+			return
+		}
+		if repo.CurrentUsage > 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("force_destroy"),
+				"Borg repo contains data",
+				fmt.Sprintf("Borg repo %q (%s) still contains %.2f MB of data. "+
+					"Set force_destroy to true and apply the change before "+
+					"destroying or replacing it.",
+					data.Name.ValueString(), data.Id.ValueString(), repo.CurrentUsage),
+			)
+			return
+		}
+	}
+
 	args := gql.Arguments{"id": gql.Required(data.Id.ValueString())}
-	if err := r.client.Mutation("repoDelete", &BorgRepoDeletePayload{}, args); err != nil {
-		resp.Diagnostics.AddError("Failed to delete borg repo", err.Error())
+	if err := r.client.Mutation(ctx, "repoDelete", &BorgRepoDeletePayload{}, args); err != nil {
+		addClientError(&resp.Diagnostics, "Failed to delete borg repo", err)
 	}
 
 	tflog.Trace(ctx, "deleted repo", map[string]interface{}{
@@ -514,4 +868,32 @@ func (r *BorgRepoResource) ImportState(
 	resp *resource.ImportStateResponse,
 ) {
 	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
+	resp.Diagnostics.Append(
+		resp.State.SetAttribute(ctx, path.Root("deletion_protection"), true)...,
+	)
+	resp.Diagnostics.Append(
+		resp.State.SetAttribute(ctx, path.Root("force_destroy"), false)...,
+	)
+	resp.Diagnostics.Append(
+		resp.State.SetAttribute(ctx, path.Root("wait_for_ready"), true)...,
+	)
+}
+
+// knownFromState returns the planned value with every unknown value replaced
+// by its prior value in state, for updates which don't change the remote
+// object.
+func knownFromState(plan, state tftypes.Value) (tftypes.Value, error) {
+	return tftypes.Transform(plan, func(
+		p *tftypes.AttributePath,
+		v tftypes.Value,
+	) (tftypes.Value, error) {
+		if v.IsKnown() {
+			return v, nil
+		}
+		prior, _, err := tftypes.WalkAttributePath(state, p)
+		if err != nil {
+			return tftypes.NewValue(v.Type(), nil), nil
+		}
+		return prior.(tftypes.Value), nil
+	})
 }
@@ -1,11 +1,19 @@
 package provider
 
 import (
+	"context"
 	"fmt"
+	"reflect"
 	"regexp"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 )
 
@@ -45,6 +53,8 @@ func TestAccBorgRepoResource_minimal(t *testing.T) {
 						},
 					),
 					resource.TestCheckResourceAttr(id, "current_usage", "0"),
+					resource.TestCheckResourceAttr(id, "current_usage_bytes", "0"),
+					resource.TestCheckResourceAttr(id, "current_usage_percent", "0"),
 					resource.TestCheckResourceAttr(id, "encryption", "none"),
 					resource.TestCheckResourceAttr(id, "format", "borg1"),
 					resource.TestCheckResourceAttr(id, "full_access_keys.#", "0"),
@@ -52,6 +62,7 @@ func TestAccBorgRepoResource_minimal(t *testing.T) {
 					resource.TestCheckResourceAttr(id, "last_modified", ""),
 					resource.TestCheckResourceAttr(id, "name", name),
 					resource.TestCheckResourceAttr(id, "quota", "0"),
+					resource.TestCheckResourceAttr(id, "quota_size", "0MiB"),
 					resource.TestCheckResourceAttr(id, "quota_enabled", "false"),
 					resource.TestCheckResourceAttr(id, "region", region),
 					resource.TestMatchResourceAttr(
@@ -71,12 +82,19 @@ func TestAccBorgRepoResource_minimal(t *testing.T) {
 					resource.TestCheckResourceAttr(id, "sftp_enabled", "false"),
 				),
 			},
+			// Empty plan testing
+			{
+				Config:   testAccBorgRepoResourceConfig_minimal(name, region),
+				PlanOnly: true,
+			},
 			// ImportState testing
 			{
 				ResourceName:      id,
 				ImportState:       true,
 				ImportStateVerify: true,
 				ImportStateId:     name,
+				// Imported repos are always protected from deletion.
+				ImportStateVerifyIgnore: []string{"deletion_protection"},
 			},
 			// Update and Read testing
 			{
@@ -86,6 +104,11 @@ func TestAccBorgRepoResource_minimal(t *testing.T) {
 					resource.TestCheckResourceAttr(id, "region", region),
 				),
 			},
+			// Empty plan testing
+			{
+				Config:   testAccBorgRepoResourceConfig_minimal(name+"_new", region),
+				PlanOnly: true,
+			},
 			// Delete testing automatically occurs in TestCase
 		},
 	})
@@ -131,6 +154,8 @@ func TestAccBorgRepoResource_full(t *testing.T) {
 						},
 					),
 					resource.TestCheckResourceAttr(id, "current_usage", "0"),
+					resource.TestCheckResourceAttr(id, "current_usage_bytes", "0"),
+					resource.TestCheckResourceAttr(id, "current_usage_percent", "0"),
 					resource.TestCheckResourceAttr(id, "encryption", "none"),
 					resource.TestCheckResourceAttr(id, "format", "borg1"),
 					resource.TestCheckResourceAttr(id, "full_access_keys.#", "1"),
@@ -138,6 +163,7 @@ func TestAccBorgRepoResource_full(t *testing.T) {
 					resource.TestCheckResourceAttr(id, "last_modified", ""),
 					resource.TestCheckResourceAttr(id, "name", name),
 					resource.TestCheckResourceAttr(id, "quota", "10000"),
+					resource.TestCheckResourceAttr(id, "quota_size", "10000MiB"),
 					resource.TestCheckResourceAttr(id, "quota_enabled", "true"),
 					resource.TestCheckResourceAttr(id, "region", region),
 					resource.TestMatchResourceAttr(
@@ -157,12 +183,19 @@ func TestAccBorgRepoResource_full(t *testing.T) {
 					resource.TestCheckResourceAttr(id, "sftp_enabled", "true"),
 				),
 			},
+			// Empty plan testing
+			{
+				Config:   testAccBorgRepoResourceConfig_full(name, region),
+				PlanOnly: true,
+			},
 			// ImportState testing
 			{
 				ResourceName:      id,
 				ImportState:       true,
 				ImportStateVerify: true,
 				ImportStateId:     name,
+				// Imported repos are always protected from deletion.
+				ImportStateVerifyIgnore: []string{"deletion_protection"},
 			},
 			// Update and Read testing
 			{
@@ -172,14 +205,503 @@ func TestAccBorgRepoResource_full(t *testing.T) {
 					resource.TestCheckResourceAttr(id, "region", region),
 				),
 			},
+			// Empty plan testing
+			{
+				Config:   testAccBorgRepoResourceConfig_full(name+"_new", region),
+				PlanOnly: true,
+			},
 			// Delete testing automatically occurs in TestCase
 		},
 	})
 }
 
+func TestAccBorgRepoResource_normalised(t *testing.T) {
+	name := "terraform_test"
+
+	id := "borgbase_borg_repo.test_normalised"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccBorgRepoResourceConfig_normalised(name),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(id, "borg_version", "latest"),
+					resource.TestCheckResourceAttr(
+						id,
+						"compaction.hour_timezone",
+						"Etc/UTC",
+					),
+				),
+			},
+			// Empty plan testing
+			{
+				Config:   testAccBorgRepoResourceConfig_normalised(name),
+				PlanOnly: true,
+			},
+			// Delete testing automatically occurs in TestCase
+		},
+	})
+}
+
+// testBorgRepoConfig builds a borg repo configuration from the given
+// attribute values, leaving every other attribute null.
+func testBorgRepoConfig(
+	t *testing.T,
+	values map[string]tftypes.Value,
+) tfsdk.Config {
+	t.Helper()
+
+	var schemaResp fwresource.SchemaResponse
+	NewBorgRepoResource().Schema(
+		context.Background(),
+		fwresource.SchemaRequest{},
+		&schemaResp,
+	)
+
+	objectType := schemaResp.Schema.Type().TerraformType(context.Background())
+	return tfsdk.Config{
+		Schema: schemaResp.Schema,
+		Raw:    testObjectValue(t, objectType.(tftypes.Object), values),
+	}
+}
+
+func testKeySet(keys ...string) tftypes.Value {
+	elements := make([]tftypes.Value, len(keys))
+	for i, key := range keys {
+		elements[i] = tftypes.NewValue(tftypes.String, key)
+	}
+	return tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, elements)
+}
+
+func TestBorgRepoResourceValidateConfig(t *testing.T) {
+	for name, tc := range map[string]struct {
+		values map[string]tftypes.Value
+		errors []path.Path
+	}{
+		"minimal": {
+			values: map[string]tftypes.Value{
+				"name":   tftypes.NewValue(tftypes.String, "test"),
+				"region": tftypes.NewValue(tftypes.String, "eu"),
+			},
+		},
+		"distinct keys": {
+			values: map[string]tftypes.Value{
+				"append_only":      tftypes.NewValue(tftypes.Bool, true),
+				"append_only_keys": testKeySet("1"),
+				"full_access_keys": testKeySet("2"),
+				"rsync_keys":       testKeySet("3"),
+			},
+		},
+		"full access and append only": {
+			values: map[string]tftypes.Value{
+				"append_only":      tftypes.NewValue(tftypes.Bool, true),
+				"append_only_keys": testKeySet("1", "2"),
+				"full_access_keys": testKeySet("2"),
+			},
+			errors: []path.Path{
+				path.Root("append_only_keys").AtSetValue(types.StringValue("2")),
+			},
+		},
+		"full access and rsync": {
+			values: map[string]tftypes.Value{
+				"full_access_keys": testKeySet("1"),
+				"rsync_keys":       testKeySet("1"),
+			},
+			errors: []path.Path{
+				path.Root("rsync_keys").AtSetValue(types.StringValue("1")),
+			},
+		},
+		"unknown keys": {
+			values: map[string]tftypes.Value{
+				"full_access_keys": testKeySet("1"),
+				"rsync_keys": tftypes.NewValue(
+					tftypes.Set{ElementType: tftypes.String},
+					tftypes.UnknownValue,
+				),
+			},
+		},
+		"append only keys without append only": {
+			values: map[string]tftypes.Value{
+				"append_only_keys": testKeySet("1"),
+			},
+			errors: []path.Path{path.Root("append_only_keys")},
+		},
+		"append only keys with unknown append only": {
+			values: map[string]tftypes.Value{
+				"append_only":      tftypes.NewValue(tftypes.Bool, tftypes.UnknownValue),
+				"append_only_keys": testKeySet("1"),
+			},
+		},
+		"empty append only keys": {
+			values: map[string]tftypes.Value{
+				"append_only":      tftypes.NewValue(tftypes.Bool, false),
+				"append_only_keys": testKeySet(),
+			},
+		},
+		"quota enabled without quota": {
+			values: map[string]tftypes.Value{
+				"quota_enabled": tftypes.NewValue(tftypes.Bool, true),
+			},
+			errors: []path.Path{path.Root("quota")},
+		},
+		"quota enabled with quota": {
+			values: map[string]tftypes.Value{
+				"quota":         tftypes.NewValue(tftypes.Number, 1000),
+				"quota_enabled": tftypes.NewValue(tftypes.Bool, true),
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := NewBorgRepoResource().(fwresource.ResourceWithValidateConfig)
+			req := fwresource.ValidateConfigRequest{
+				Config: testBorgRepoConfig(t, tc.values),
+			}
+			var resp fwresource.ValidateConfigResponse
+			r.ValidateConfig(context.Background(), req, &resp)
+
+			errors := resp.Diagnostics.Errors()
+			if len(errors) != len(tc.errors) {
+				t.Fatalf("expected %d errors, got %d: %v",
+					len(tc.errors), len(errors), errors)
+			}
+			for i, expected := range tc.errors {
+				withPath, ok := errors[i].(diag.DiagnosticWithPath)
+				if !ok || !withPath.Path().Equal(expected) {
+					t.Errorf("expected error at %s, got %v", expected, errors[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBorgRepoResourceModifyPlan_quota(t *testing.T) {
+	ctx := context.Background()
+
+	for name, tc := range map[string]struct {
+		config            map[string]tftypes.Value
+		plan              map[string]tftypes.Value
+		expectedQuota     types.Int64
+		expectedQuotaSize types.String
+	}{
+		"quota size": {
+			config: map[string]tftypes.Value{
+				"quota_size": tftypes.NewValue(tftypes.String, "1TiB"),
+			},
+			plan: map[string]tftypes.Value{
+				"quota":      tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
+				"quota_size": tftypes.NewValue(tftypes.String, "1TiB"),
+			},
+			expectedQuota:     types.Int64Value(1048576),
+			expectedQuotaSize: types.StringValue("1TiB"),
+		},
+		"quota": {
+			config: map[string]tftypes.Value{
+				"quota": tftypes.NewValue(tftypes.Number, 2048),
+			},
+			plan: map[string]tftypes.Value{
+				"quota":      tftypes.NewValue(tftypes.Number, 2048),
+				"quota_size": tftypes.NewValue(tftypes.String, "1GiB"),
+			},
+			expectedQuota:     types.Int64Value(2048),
+			expectedQuotaSize: types.StringValue("2GiB"),
+		},
+		"unknown quota": {
+			config: map[string]tftypes.Value{
+				"quota": tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
+			},
+			plan: map[string]tftypes.Value{
+				"quota":      tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
+				"quota_size": tftypes.NewValue(tftypes.String, "1GiB"),
+			},
+			expectedQuota:     types.Int64Unknown(),
+			expectedQuotaSize: types.StringUnknown(),
+		},
+		"neither": {
+			plan: map[string]tftypes.Value{
+				"quota":      tftypes.NewValue(tftypes.Number, 1024),
+				"quota_size": tftypes.NewValue(tftypes.String, "1GiB"),
+			},
+			expectedQuota:     types.Int64Value(1024),
+			expectedQuotaSize: types.StringValue("1GiB"),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			config := testBorgRepoConfig(t, tc.config)
+			plan := tfsdk.Plan{
+				Schema: config.Schema,
+				Raw: testObjectValue(
+					t,
+					config.Raw.Type().(tftypes.Object),
+					tc.plan,
+				),
+			}
+
+			r := NewBorgRepoResource().(fwresource.ResourceWithModifyPlan)
+			req := fwresource.ModifyPlanRequest{Config: config, Plan: plan}
+			resp := fwresource.ModifyPlanResponse{Plan: plan}
+			r.ModifyPlan(ctx, req, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatal(resp.Diagnostics)
+			}
+
+			var data BorgRepoModel
+			if diagnostics := resp.Plan.Get(ctx, &data); diagnostics.HasError() {
+				t.Fatal(diagnostics)
+			}
+			if !data.Quota.Equal(tc.expectedQuota) {
+				t.Errorf("quota: expected %s, got %s", tc.expectedQuota, data.Quota)
+			}
+			if !data.QuotaSize.Equal(tc.expectedQuotaSize) {
+				t.Errorf("quota_size: expected %s, got %s",
+					tc.expectedQuotaSize, data.QuotaSize)
+			}
+		})
+	}
+}
+
+func TestBorgRepoResourceDelete(t *testing.T) {
+	ctx := context.Background()
+
+	for name, tc := range map[string]struct {
+		deletionProtection tftypes.Value
+		forceDestroy       bool
+		repos              []map[string]interface{}
+		expectedError      bool
+		expectedOperations []string
+	}{
+		"protected": {
+			deletionProtection: tftypes.NewValue(tftypes.Bool, true),
+			expectedError:      true,
+		},
+		"protection unset": {
+			deletionProtection: tftypes.NewValue(tftypes.Bool, nil),
+			expectedError:      true,
+		},
+		"empty": {
+			deletionProtection: tftypes.NewValue(tftypes.Bool, false),
+			repos:              []map[string]interface{}{{"id": "abc", "currentUsage": 0}},
+			expectedOperations: []string{"repoList", "repoDelete"},
+		},
+		"contains data": {
+			deletionProtection: tftypes.NewValue(tftypes.Bool, false),
+			repos:              []map[string]interface{}{{"id": "abc", "currentUsage": 0.5}},
+			expectedError:      true,
+			expectedOperations: []string{"repoList"},
+		},
+		"contains data, forced": {
+			deletionProtection: tftypes.NewValue(tftypes.Bool, false),
+			forceDestroy:       true,
+			expectedOperations: []string{"repoDelete"},
+		},
+		"already deleted": {
+			deletionProtection: tftypes.NewValue(tftypes.Bool, false),
+			repos:              []map[string]interface{}{},
+			expectedOperations: []string{"repoList"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			client, api := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+				if req.Operation == "repoList" {
+					return tc.repos, nil
+				}
+				return map[string]interface{}{"ok": true}, nil
+			})
+
+			config := testBorgRepoConfig(t, map[string]tftypes.Value{
+				"deletion_protection": tc.deletionProtection,
+				"force_destroy":       tftypes.NewValue(tftypes.Bool, tc.forceDestroy),
+				"id":                  tftypes.NewValue(tftypes.String, "abc"),
+				"name":                tftypes.NewValue(tftypes.String, "test"),
+			})
+			state := tfsdk.State{Schema: config.Schema, Raw: config.Raw}
+
+			r := &BorgRepoResource{client: client}
+			resp := fwresource.DeleteResponse{State: state}
+			r.Delete(ctx, fwresource.DeleteRequest{State: state}, &resp)
+			if resp.Diagnostics.HasError() != tc.expectedError {
+				t.Errorf("expected error %t, got %s", tc.expectedError, resp.Diagnostics)
+			}
+
+			var operations []string
+			for _, req := range api.Requests() {
+				operations = append(operations, req.Operation)
+			}
+			if !reflect.DeepEqual(operations, tc.expectedOperations) {
+				t.Errorf("expected operations %v, got %v", tc.expectedOperations, operations)
+			}
+		})
+	}
+}
+
+func TestBorgRepoResourceCreate_waitForReady(t *testing.T) {
+	ctx := context.Background()
+
+	initialDelay := repoReadyInitialDelay
+	repoReadyInitialDelay = time.Millisecond
+	t.Cleanup(func() { repoReadyInitialDelay = initialDelay })
+
+	readyRepo := BorgRepoPayload{Id: "abc", Name: "test", RepoPath: "ssh://abc@abc.repo.borgbase.com/./repo"}
+	readyRepo.Server.Hostname = "abc.repo.borgbase.com"
+	readyRepo.Server.FingerprintEcdsa = "ecdsa"
+	readyRepo.Server.FingerprintEd25519 = "ed25519"
+	readyRepo.Server.FingerprintRsa = "rsa"
+
+	timeoutsType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"create": tftypes.String,
+		"delete": tftypes.String,
+		"read":   tftypes.String,
+		"update": tftypes.String,
+	}}
+
+	for name, tc := range map[string]struct {
+		waitForReady       bool
+		pendingPolls       int
+		createTimeout      interface{}
+		expectedError      bool
+		expectedRepoPath   string
+		expectedOperations int
+	}{
+		"ready after polling": {
+			waitForReady:       true,
+			pendingPolls:       2,
+			expectedRepoPath:   readyRepo.RepoPath,
+			expectedOperations: 4,
+		},
+		"not waiting": {
+			pendingPolls:       2,
+			expectedOperations: 1,
+		},
+		"timed out": {
+			waitForReady:  true,
+			pendingPolls:  1 << 30,
+			createTimeout: "50ms",
+			expectedError: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			polls := 0
+			client, api := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+				pending := BorgRepoPayload{Id: "abc", Name: "test"}
+				switch req.Operation {
+				case "repoAdd":
+					return BorgRepoAddPayload{RepoAdded: pending}, nil
+				case "repoList":
+					if polls++; polls <= tc.pendingPolls {
+						return BorgReposPayload{pending}, nil
+					}
+					return BorgReposPayload{readyRepo}, nil
+				default:
+					return nil, fmt.Errorf("unexpected operation %s", req.Operation)
+				}
+			})
+
+			config := testBorgRepoConfig(t, map[string]tftypes.Value{
+				"name":           tftypes.NewValue(tftypes.String, "test"),
+				"region":         tftypes.NewValue(tftypes.String, "eu"),
+				"wait_for_ready": tftypes.NewValue(tftypes.Bool, tc.waitForReady),
+				"timeouts": tftypes.NewValue(timeoutsType, map[string]tftypes.Value{
+					"create": tftypes.NewValue(tftypes.String, tc.createTimeout),
+					"delete": tftypes.NewValue(tftypes.String, nil),
+					"read":   tftypes.NewValue(tftypes.String, nil),
+					"update": tftypes.NewValue(tftypes.String, nil),
+				}),
+			})
+			plan := tfsdk.Plan{Schema: config.Schema, Raw: config.Raw}
+			objectType := config.Raw.Type()
+
+			r := &BorgRepoResource{client: client}
+			resp := fwresource.CreateResponse{
+				State: tfsdk.State{Schema: config.Schema, Raw: tftypes.NewValue(objectType, nil)},
+			}
+			r.Create(ctx, fwresource.CreateRequest{Plan: plan, Config: config}, &resp)
+			if resp.Diagnostics.HasError() != tc.expectedError {
+				t.Fatalf("expected error %t, got %s", tc.expectedError, resp.Diagnostics)
+			}
+
+			// The repo is saved even if it doesn't become ready, so that it
+			// isn't left behind.
+			var data BorgRepoModel
+			if diagnostics := resp.State.Get(ctx, &data); diagnostics.HasError() {
+				t.Fatal(diagnostics)
+			}
+			if data.Id.ValueString() != "abc" {
+				t.Errorf("id: expected abc, got %s", data.Id)
+			}
+			if tc.expectedError {
+				return
+			}
+			if data.RepoPath.ValueString() != tc.expectedRepoPath {
+				t.Errorf("repo_path: expected %q, got %s", tc.expectedRepoPath, data.RepoPath)
+			}
+			if operations := len(api.Requests()); operations != tc.expectedOperations {
+				t.Errorf("expected %d operations, got %d", tc.expectedOperations, operations)
+			}
+		})
+	}
+}
+
+func TestBorgRepoResourceCreate_timedOutReplaced(t *testing.T) {
+	ctx := context.Background()
+
+	initialDelay := repoReadyInitialDelay
+	repoReadyInitialDelay = time.Millisecond
+	t.Cleanup(func() { repoReadyInitialDelay = initialDelay })
+
+	pending := BorgRepoPayload{Id: "abc", Name: "test"}
+	client, api := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		switch req.Operation {
+		case "repoAdd":
+			return BorgRepoAddPayload{RepoAdded: pending}, nil
+		case "repoList":
+			return BorgReposPayload{pending}, nil
+		case "repoDelete":
+			return BorgRepoDeletePayload{}, nil
+		default:
+			return nil, fmt.Errorf("unexpected operation %s", req.Operation)
+		}
+	})
+
+	config := testBorgRepoConfig(t, map[string]tftypes.Value{
+		"deletion_protection": tftypes.NewValue(tftypes.Bool, true),
+		"name":                tftypes.NewValue(tftypes.String, "test"),
+		"region":              tftypes.NewValue(tftypes.String, "eu"),
+		"wait_for_ready":      tftypes.NewValue(tftypes.Bool, true),
+	})
+	plan := tfsdk.Plan{Schema: config.Schema, Raw: config.Raw}
+
+	// The repo never becomes ready, so waiting for it times out.
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	r := &BorgRepoResource{client: client}
+	createResp := fwresource.CreateResponse{
+		State: tfsdk.State{Schema: config.Schema, Raw: tftypes.NewValue(config.Raw.Type(), nil)},
+	}
+	r.Create(timeout, fwresource.CreateRequest{Plan: plan, Config: config}, &createResp)
+	if !createResp.Diagnostics.HasError() {
+		t.Fatal("expected error")
+	}
+
+	// Terraform replaces the tainted repo, which must be possible without
+	// disabling deletion protection first.
+	deleteResp := fwresource.DeleteResponse{State: createResp.State}
+	r.Delete(ctx, fwresource.DeleteRequest{State: createResp.State}, &deleteResp)
+	if deleteResp.Diagnostics.HasError() {
+		t.Fatal(deleteResp.Diagnostics)
+	}
+	requests := api.Requests()
+	if operation := requests[len(requests)-1].Operation; operation != "repoDelete" {
+		t.Errorf("expected the repo to be deleted, got %s", operation)
+	}
+}
+
 func testAccBorgRepoResourceConfig_minimal(name, region string) string {
 	return fmt.Sprintf(`
 resource "borgbase_borg_repo" "test_minimal" {
+	deletion_protection = false
 	name = %q
 	region = %q
 }`, name, region)
@@ -214,6 +736,7 @@ resource "borgbase_borg_repo" "test_full" {
     interval      = 6
     interval_unit = "weeks"
   }
+  deletion_protection = false
 	full_access_keys = [borgbase_ssh_key.terraform_full_access.id]
 	name             = %q
   quota            = 10000
@@ -223,3 +746,113 @@ resource "borgbase_borg_repo" "test_full" {
   sftp_enabled     = true
 }`, name, region)
 }
+
+func testAccBorgRepoResourceConfig_normalised(name string) string {
+	return fmt.Sprintf(`
+resource "borgbase_borg_repo" "test_normalised" {
+  borg_version = "latest"
+  compaction = {
+    enabled       = true
+    hour          = 3
+    hour_timezone = "Etc/UTC"
+    interval      = 1
+    interval_unit = "weeks"
+  }
+  deletion_protection = false
+  name                = %q
+  region              = "eu"
+}`, name)
+}
+
+func TestBorgRepoResourceUpdate_localOnly(t *testing.T) {
+	ctx := context.Background()
+
+	client, api := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		return nil, fmt.Errorf("unexpected operation %s", req.Operation)
+	})
+
+	r := &BorgRepoResource{client: client}
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+	state := testStateFixture(t, "borg_repo", 1, schemaResp.Schema)
+
+	var data BorgRepoModel
+	if diagnostics := state.Get(ctx, &data); diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+	prior := data
+	data.DeletionProtection = types.BoolValue(!prior.DeletionProtection.ValueBool())
+	data.SftpUrl = types.StringUnknown()
+	plan := tfsdk.Plan{Schema: schemaResp.Schema, Raw: state.Raw}
+	if diagnostics := plan.Set(ctx, &data); diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+
+	resp := fwresource.UpdateResponse{State: state}
+	r.Update(ctx, fwresource.UpdateRequest{State: state, Plan: plan}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+	if requests := api.Requests(); len(requests) != 0 {
+		t.Errorf("expected no operations, got %v", requests)
+	}
+
+	var updated BorgRepoModel
+	if diagnostics := resp.State.Get(ctx, &updated); diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+	if !updated.DeletionProtection.Equal(data.DeletionProtection) {
+		t.Errorf("deletion_protection: expected %s, got %s", data.DeletionProtection, updated.DeletionProtection)
+	}
+	if !updated.SftpUrl.Equal(prior.SftpUrl) {
+		t.Errorf("sftp_url: expected %s, got %s", prior.SftpUrl, updated.SftpUrl)
+	}
+}
+
+func TestBorgRepoResourceCreate_unresolvedKeyNames(t *testing.T) {
+	ctx := context.Background()
+
+	client, api := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		switch req.Operation {
+		case "sshList":
+			return SshKeysPayload{{Id: "1", Name: "laptop"}}, nil
+		case "repoAdd":
+			return BorgRepoAddPayload{RepoAdded: BorgRepoPayload{
+				Id:             "abc",
+				Name:           "test",
+				FullAccessKeys: []string{"1"},
+			}}, nil
+		default:
+			return nil, fmt.Errorf("unexpected operation %s", req.Operation)
+		}
+	})
+
+	// The key was created in the same apply, so it couldn't be resolved
+	// while planning.
+	config := testBorgRepoConfig(t, map[string]tftypes.Value{
+		"full_access_key_names": testKeySet("laptop"),
+		"full_access_keys":      tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, tftypes.UnknownValue),
+		"name":                  tftypes.NewValue(tftypes.String, "test"),
+		"region":                tftypes.NewValue(tftypes.String, "eu"),
+	})
+	plan := tfsdk.Plan{Schema: config.Schema, Raw: config.Raw}
+
+	r := &BorgRepoResource{client: client}
+	resp := fwresource.CreateResponse{
+		State: tfsdk.State{Schema: config.Schema, Raw: tftypes.NewValue(config.Raw.Type(), nil)},
+	}
+	r.Create(ctx, fwresource.CreateRequest{Plan: plan, Config: config}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+
+	var keys interface{}
+	for _, req := range api.Requests() {
+		if req.Operation == "repoAdd" {
+			keys = req.Variables["fullAccessKeys"]
+		}
+	}
+	if !reflect.DeepEqual(keys, []interface{}{"1"}) {
+		t.Errorf("fullAccessKeys: expected [1], got %v", keys)
+	}
+}
@@ -0,0 +1,186 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.ResourceWithUpgradeState = &BorgRepoResource{}
+
+func (r *BorgRepoResource) UpgradeState(
+	ctx context.Context,
+) map[int64]resource.StateUpgrader {
+	schemaV0 := borgRepoSchemaV0()
+
+	return map[int64]resource.StateUpgrader{
+		0: {
+			PriorSchema:   &schemaV0,
+			StateUpgrader: upgradeBorgRepoStateV0,
+		},
+	}
+}
+
+// BorgRepoModelV0 is the borg repo state before the SSH key lists were
+// converted to sets.
+type BorgRepoModelV0 struct {
+	AlertDays      types.Int64   `tfsdk:"alert_days"`
+	AppendOnly     types.Bool    `tfsdk:"append_only"`
+	AppendOnlyKeys types.List    `tfsdk:"append_only_keys"`
+	BorgVersion    types.String  `tfsdk:"borg_version"`
+	Compaction     types.Object  `tfsdk:"compaction"`
+	CreatedAt      types.String  `tfsdk:"created_at"`
+	CurrentUsage   types.Float64 `tfsdk:"current_usage"`
+	Encryption     types.String  `tfsdk:"encryption"`
+	Format         types.String  `tfsdk:"format"`
+	FullAccessKeys types.List    `tfsdk:"full_access_keys"`
+	Id             types.String  `tfsdk:"id"`
+	LastModified   types.String  `tfsdk:"last_modified"`
+	Name           types.String  `tfsdk:"name"`
+	Quota          types.Int64   `tfsdk:"quota"`
+	QuotaEnabled   types.Bool    `tfsdk:"quota_enabled"`
+	Region         types.String  `tfsdk:"region"`
+	RepoPath       types.String  `tfsdk:"repo_path"`
+	RsyncKeys      types.List    `tfsdk:"rsync_keys"`
+	Server         types.Object  `tfsdk:"server"`
+	SftpEnabled    types.Bool    `tfsdk:"sftp_enabled"`
+}
+
+// borgRepoSchemaV0 returns the attribute types of version 0 of the borg repo
+// schema. It must not be changed, as it is used to decode existing state.
+func borgRepoSchemaV0() schema.Schema {
+	keys := schema.ListAttribute{
+		ElementType: types.StringType,
+		Computed:    true,
+		Optional:    true,
+	}
+	computedString := schema.StringAttribute{Computed: true}
+
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"alert_days":       schema.Int64Attribute{Computed: true, Optional: true},
+			"append_only":      schema.BoolAttribute{Computed: true, Optional: true},
+			"append_only_keys": keys,
+			"borg_version":     schema.StringAttribute{Computed: true, Optional: true},
+			"compaction": schema.SingleNestedAttribute{
+				Computed: true,
+				Optional: true,
+				Attributes: map[string]schema.Attribute{
+					"enabled":       schema.BoolAttribute{Required: true},
+					"hour":          schema.Int64Attribute{Required: true},
+					"hour_timezone": schema.StringAttribute{Required: true},
+					"interval":      schema.Int64Attribute{Required: true},
+					"interval_unit": schema.StringAttribute{Required: true},
+				},
+			},
+			"created_at":       computedString,
+			"current_usage":    schema.Float64Attribute{Computed: true},
+			"encryption":       computedString,
+			"format":           computedString,
+			"full_access_keys": keys,
+			"id":               computedString,
+			"last_modified":    computedString,
+			"name":             schema.StringAttribute{Required: true},
+			"quota":            schema.Int64Attribute{Computed: true, Optional: true},
+			"quota_enabled":    schema.BoolAttribute{Computed: true, Optional: true},
+			"region":           schema.StringAttribute{Required: true},
+			"repo_path":        computedString,
+			"rsync_keys":       keys,
+			"server": schema.SingleNestedAttribute{
+				Computed: true,
+				Attributes: map[string]schema.Attribute{
+					"fingerprint_ecdsa":   computedString,
+					"fingerprint_ed25519": computedString,
+					"fingerprint_rsa":     computedString,
+					"hostname":            computedString,
+					"id":                  computedString,
+					"location":            computedString,
+					"public":              schema.BoolAttribute{Computed: true},
+					"region":              computedString,
+				},
+			},
+			"sftp_enabled": schema.BoolAttribute{Computed: true, Optional: true},
+		},
+	}
+}
+
+// listToSet converts a list of strings to a set of strings, preserving null
+// and unknown values.
+func listToSet(list types.List) (types.Set, diag.Diagnostics) {
+	switch {
+	case list.IsNull():
+		return types.SetNull(types.StringType), nil
+	case list.IsUnknown():
+		return types.SetUnknown(types.StringType), nil
+	default:
+		return types.SetValue(types.StringType, list.Elements())
+	}
+}
+
+// upgradeBorgRepoStateV0 converts the SSH key lists to sets.
+func upgradeBorgRepoStateV0(
+	ctx context.Context,
+	req resource.UpgradeStateRequest,
+	resp *resource.UpgradeStateResponse,
+) {
+	var prior BorgRepoModelV0
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	sets := make(map[string]types.Set, 3)
+	for name, list := range map[string]types.List{
+		"append_only_keys": prior.AppendOnlyKeys,
+		"full_access_keys": prior.FullAccessKeys,
+		"rsync_keys":       prior.RsyncKeys,
+	} {
+		set, diagnostics := listToSet(list)
+		resp.Diagnostics.Append(diagnostics...)
+		sets[name] = set
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data := BorgRepoModel{
+		AlertDays:          prior.AlertDays,
+		AppendOnly:         prior.AppendOnly,
+		AppendOnlyKeys:     sets["append_only_keys"],
+		AppendOnlyKeyNames: types.SetNull(types.StringType),
+		BorgVersion:        prior.BorgVersion,
+		Compaction:         prior.Compaction,
+		CreatedAt:          prior.CreatedAt,
+		CurrentUsage:       prior.CurrentUsage,
+		DeletionProtection: types.BoolValue(true),
+		Encryption:         prior.Encryption,
+		ForceDestroy:       types.BoolValue(false),
+		Format:             prior.Format,
+		FullAccessKeys:     sets["full_access_keys"],
+		FullAccessKeyNames: types.SetNull(types.StringType),
+		Id:                 prior.Id,
+		LastModified:       prior.LastModified,
+		Name:               prior.Name,
+		Quota:              prior.Quota,
+		QuotaEnabled:       prior.QuotaEnabled,
+		Region:             prior.Region,
+		RepoPath:           prior.RepoPath,
+		RsyncKeys:          sets["rsync_keys"],
+		RsyncKeyNames:      types.SetNull(types.StringType),
+		Server:             prior.Server,
+		SftpEnabled:        prior.SftpEnabled,
+		Timeouts:           types.ObjectNull(timeoutsAttributes),
+		WaitForReady:       types.BoolValue(true),
+	}
+	data.setConnectionAttributes(BorgRepoPayload{
+		RepoPath:    prior.RepoPath.ValueString(),
+		SftpEnabled: prior.SftpEnabled.ValueBool(),
+	})
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
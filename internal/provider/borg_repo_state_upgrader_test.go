@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestBorgRepoResourceUpgradeStateV0(t *testing.T) {
+	ctx := context.Background()
+	r := &BorgRepoResource{}
+
+	state := testUpgradeState(t, r, "borg_repo", 0)
+
+	var data BorgRepoModel
+	if diagnostics := state.Get(ctx, &data); diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+
+	for name, tc := range map[string]struct {
+		actual   types.Set
+		expected []string
+	}{
+		"append_only_keys": {data.AppendOnlyKeys, []string{"2", "3"}},
+		"full_access_keys": {data.FullAccessKeys, []string{"1"}},
+		"rsync_keys":       {data.RsyncKeys, []string{}},
+	} {
+		expected, diagnostics := types.SetValueFrom(ctx, types.StringType, tc.expected)
+		if diagnostics.HasError() {
+			t.Fatal(diagnostics)
+		}
+		if !tc.actual.Equal(expected) {
+			t.Errorf("%s: expected %s, got %s", name, expected, tc.actual)
+		}
+	}
+	if data.Name.ValueString() != "terraform_test" {
+		t.Errorf("name: expected terraform_test, got %s", data.Name)
+	}
+	if data.Quota.ValueInt64() != 10000 {
+		t.Errorf("quota: expected 10000, got %s", data.Quota)
+	}
+	if !data.DeletionProtection.Equal(types.BoolValue(true)) {
+		t.Errorf("deletion_protection: expected true, got %s", data.DeletionProtection)
+	}
+	if !data.ForceDestroy.Equal(types.BoolValue(false)) {
+		t.Errorf("force_destroy: expected false, got %s", data.ForceDestroy)
+	}
+	if !data.WaitForReady.Equal(types.BoolValue(true)) {
+		t.Errorf("wait_for_ready: expected true, got %s", data.WaitForReady)
+	}
+	if data.SshUser.IsNull() {
+		t.Errorf("ssh_user: expected value derived from repo_path, got null")
+	}
+}
+
+func TestBorgRepoResourceState_current(t *testing.T) {
+	ctx := context.Background()
+	r := &BorgRepoResource{}
+
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	state := testStateFixture(t, "borg_repo", schemaResp.Schema.Version, schemaResp.Schema)
+
+	var data BorgRepoModel
+	if diagnostics := state.Get(ctx, &data); diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+	if data.Id.ValueString() != "abc123" {
+		t.Errorf("id: expected abc123, got %s", data.Id)
+	}
+	if data.QuotaSize.ValueString() != "10000MiB" {
+		t.Errorf("quota_size: expected 10000MiB, got %s", data.QuotaSize)
+	}
+	if !data.DeletionProtection.ValueBool() {
+		t.Errorf("deletion_protection: expected true, got %s", data.DeletionProtection)
+	}
+}
@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ datasource.DataSource = &BorgmaticConfigDataSource{}
+
+func NewBorgmaticConfigDataSource() datasource.DataSource {
+	return &BorgmaticConfigDataSource{}
+}
+
+type BorgmaticConfigDataSource struct {
+	repoLookupDataSource
+}
+
+func (d *BorgmaticConfigDataSource) Metadata(
+	ctx context.Context,
+	req datasource.MetadataRequest,
+	resp *datasource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_borgmatic_config"
+}
+
+func (d *BorgmaticConfigDataSource) Schema(
+	ctx context.Context,
+	req datasource.SchemaRequest,
+	resp *datasource.SchemaResponse,
+) {
+	retentionValidators := []validator.Int64{int64validator.AtLeast(1)}
+
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "[borgmatic](https://torsion.org/borgmatic/) configuration for backing up to a borg repository.",
+		Attributes: withRepoLookupAttributes(map[string]schema.Attribute{
+			"config": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Rendered borgmatic configuration file (YAML).",
+			},
+			"encryption_passcommand": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Command which prints the passphrase of the repository, e.g. `pass show borg`.",
+			},
+			"exclude_patterns": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "Patterns of paths which should not be backed up.",
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Internal BorgBase repository identifier.",
+			},
+			"known_hosts_file": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Path of the known_hosts file containing the repository server's host key (defaults to SSH's default files).",
+			},
+			"repo_path": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "SSH path where the repository can be accessed.",
+			},
+			"retention": schema.SingleNestedAttribute{
+				Optional:            true,
+				MarkdownDescription: "Which archives to keep when pruning. Archives are never pruned if unset.",
+				Attributes: map[string]schema.Attribute{
+					"keep_daily": schema.Int64Attribute{
+						Optional:            true,
+						MarkdownDescription: "Number of daily archives to keep.",
+						Validators:          retentionValidators,
+					},
+					"keep_hourly": schema.Int64Attribute{
+						Optional:            true,
+						MarkdownDescription: "Number of hourly archives to keep.",
+						Validators:          retentionValidators,
+					},
+					"keep_monthly": schema.Int64Attribute{
+						Optional:            true,
+						MarkdownDescription: "Number of monthly archives to keep.",
+						Validators:          retentionValidators,
+					},
+					"keep_weekly": schema.Int64Attribute{
+						Optional:            true,
+						MarkdownDescription: "Number of weekly archives to keep.",
+						Validators:          retentionValidators,
+					},
+					"keep_within": schema.StringAttribute{
+						Optional:            true,
+						MarkdownDescription: "Keep all archives within this time interval, e.g. `48H` or `7d`.",
+						Validators: []validator.String{
+							stringvalidator.RegexMatches(
+								keepWithinPattern,
+								"must be a number followed by one of H, d, w, m or y",
+							),
+						},
+					},
+					"keep_yearly": schema.Int64Attribute{
+						Optional:            true,
+						MarkdownDescription: "Number of yearly archives to keep.",
+						Validators:          retentionValidators,
+					},
+				},
+			},
+			"source_directories": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Required:            true,
+				MarkdownDescription: "Directories to back up.",
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+				},
+			},
+			"ssh_key_file": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Path of the private SSH key to access the repository with (defaults to SSH's default keys).",
+			},
+		}),
+	}
+}
+
+func (d *BorgmaticConfigDataSource) Read(
+	ctx context.Context,
+	req datasource.ReadRequest,
+	resp *datasource.ReadResponse,
+) {
+	var data BorgmaticConfigModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	repo := d.readRepo(ctx, data.RepoId, data.RepoName, &resp.Diagnostics)
+	if repo == nil {
+		return
+	}
+	if repo.RepoPath == "" {
+		resp.Diagnostics.AddError(
+			"Borg repo not ready",
+			fmt.Sprintf("Borg repo %s has not been assigned a repo path yet.", repo.Name),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(data.update(ctx, *repo)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tflog.Trace(ctx, "rendered borgmatic config", map[string]interface{}{
+		"id":   data.Id,
+		"name": data.RepoName,
+	})
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
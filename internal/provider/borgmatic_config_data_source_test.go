@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestBorgmaticConfigDataSourceRead(t *testing.T) {
+	ctx := context.Background()
+
+	client, _ := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		if req.Operation != "repoList" {
+			return nil, fmt.Errorf("unexpected operation %s", req.Operation)
+		}
+		return BorgReposPayload{
+			{Id: "abc", Name: "other", RepoPath: "ssh://def@def.repo.borgbase.com/./repo"},
+			{Id: "def", Name: "web", RepoPath: "ssh://abc@abc.repo.borgbase.com/./repo"},
+		}, nil
+	})
+
+	d := &BorgmaticConfigDataSource{repoLookupDataSource{client: client}}
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	retentionType := objectType.AttributeTypes["retention"].(tftypes.Object)
+	stringList := tftypes.List{ElementType: tftypes.String}
+
+	req := datasource.ReadRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw: testObjectValue(t, objectType, map[string]tftypes.Value{
+				"encryption_passcommand": tftypes.NewValue(tftypes.String, "pass show borg"),
+				"known_hosts_file":       tftypes.NewValue(tftypes.String, "/etc/borgmatic/known hosts"),
+				"repo_name":              tftypes.NewValue(tftypes.String, "web"),
+				"retention": testObjectValue(t, retentionType, map[string]tftypes.Value{
+					"keep_daily":  tftypes.NewValue(tftypes.Number, 7),
+					"keep_weekly": tftypes.NewValue(tftypes.Number, 4),
+				}),
+				"source_directories": tftypes.NewValue(stringList, []tftypes.Value{
+					tftypes.NewValue(tftypes.String, "/home"),
+					tftypes.NewValue(tftypes.String, "/etc"),
+				}),
+				"ssh_key_file": tftypes.NewValue(tftypes.String, "/root/.ssh/borgbase"),
+			}),
+		},
+	}
+	resp := datasource.ReadResponse{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+			Raw:    tftypes.NewValue(objectType, nil),
+		},
+	}
+	d.Read(ctx, req, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+
+	var data BorgmaticConfigModel
+	if diagnostics := resp.State.Get(ctx, &data); diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+	if data.RepoId.ValueString() != "def" {
+		t.Errorf("repo_id: expected def, got %s", data.RepoId)
+	}
+
+	expected := `source_directories:
+    - /home
+    - /etc
+repositories:
+    - path: ssh://abc@abc.repo.borgbase.com/./repo
+      label: web
+encryption_passcommand: pass show borg
+ssh_command: ssh -o StrictHostKeyChecking=yes -o 'UserKnownHostsFile=/etc/borgmatic/known hosts' -o IdentitiesOnly=yes -i /root/.ssh/borgbase
+keep_daily: 7
+keep_weekly: 4
+`
+	if data.Config.ValueString() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, data.Config.ValueString())
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	for word, expected := range map[string]string{
+		"":                   "''",
+		"/root/.ssh/id":      "/root/.ssh/id",
+		"~/.ssh/id_ed25519":  "~/.ssh/id_ed25519",
+		"/path with/spaces":  "'/path with/spaces'",
+		"it's":               `'it'"'"'s'`,
+		"$HOME/.ssh/id_rsa":  "'$HOME/.ssh/id_rsa'",
+		"UserKnownHostsFile": "UserKnownHostsFile",
+	} {
+		if actual := shellQuote(word); actual != expected {
+			t.Errorf("%q: expected %s, got %s", word, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"gopkg.in/yaml.v3"
+)
+
+type BorgmaticConfigModel struct {
+	Config                types.String `tfsdk:"config"`
+	EncryptionPasscommand types.String `tfsdk:"encryption_passcommand"`
+	ExcludePatterns       types.List   `tfsdk:"exclude_patterns"`
+	Id                    types.String `tfsdk:"id"`
+	KnownHostsFile        types.String `tfsdk:"known_hosts_file"`
+	RepoId                types.String `tfsdk:"repo_id"`
+	RepoName              types.String `tfsdk:"repo_name"`
+	RepoPath              types.String `tfsdk:"repo_path"`
+	Retention             types.Object `tfsdk:"retention"`
+	SourceDirectories     types.List   `tfsdk:"source_directories"`
+	SshKeyFile            types.String `tfsdk:"ssh_key_file"`
+}
+
+type RetentionModel struct {
+	KeepDaily   types.Int64  `tfsdk:"keep_daily"`
+	KeepHourly  types.Int64  `tfsdk:"keep_hourly"`
+	KeepMonthly types.Int64  `tfsdk:"keep_monthly"`
+	KeepWeekly  types.Int64  `tfsdk:"keep_weekly"`
+	KeepWithin  types.String `tfsdk:"keep_within"`
+	KeepYearly  types.Int64  `tfsdk:"keep_yearly"`
+}
+
+// keepWithinPattern matches the intervals accepted by borg prune
+// --keep-within.
+var keepWithinPattern = regexp.MustCompile(`^[0-9]+[Hdwmy]$`)
+
+// borgmaticConfig is a borgmatic configuration file, in the unnested format
+// used by borgmatic 1.8 and later. Fields are ordered the way they appear in
+// the generated YAML.
+type borgmaticConfig struct {
+	SourceDirectories     []string              `yaml:"source_directories"`
+	Repositories          []borgmaticRepository `yaml:"repositories"`
+	ExcludePatterns       []string              `yaml:"exclude_patterns,omitempty"`
+	EncryptionPasscommand string                `yaml:"encryption_passcommand,omitempty"`
+	SshCommand            string                `yaml:"ssh_command"`
+	KeepWithin            string                `yaml:"keep_within,omitempty"`
+	KeepHourly            int64                 `yaml:"keep_hourly,omitempty"`
+	KeepDaily             int64                 `yaml:"keep_daily,omitempty"`
+	KeepWeekly            int64                 `yaml:"keep_weekly,omitempty"`
+	KeepMonthly           int64                 `yaml:"keep_monthly,omitempty"`
+	KeepYearly            int64                 `yaml:"keep_yearly,omitempty"`
+}
+
+type borgmaticRepository struct {
+	Path  string `yaml:"path"`
+	Label string `yaml:"label"`
+}
+
+// update sets the computed attributes from the given repo, rendering the
+// configuration from the configured attributes.
+func (m *BorgmaticConfigModel) update(
+	ctx context.Context,
+	repo BorgRepoPayload,
+) diag.Diagnostics {
+	var diagnostics diag.Diagnostics
+
+	m.Id = types.StringValue(repo.Id)
+	m.RepoId = types.StringValue(repo.Id)
+	m.RepoName = types.StringValue(repo.Name)
+	m.RepoPath = types.StringValue(repo.RepoPath)
+
+	config := borgmaticConfig{
+		Repositories: []borgmaticRepository{
+			{Path: repo.RepoPath, Label: repo.Name},
+		},
+		EncryptionPasscommand: m.EncryptionPasscommand.ValueString(),
+		SshCommand: sshCommand(
+			m.SshKeyFile.ValueString(),
+			m.KnownHostsFile.ValueString(),
+		),
+	}
+
+	diagnostics.Append(m.SourceDirectories.ElementsAs(ctx, &config.SourceDirectories, false)...)
+	if !m.ExcludePatterns.IsNull() {
+		diagnostics.Append(m.ExcludePatterns.ElementsAs(ctx, &config.ExcludePatterns, false)...)
+	}
+	if !m.Retention.IsNull() {
+		var retention RetentionModel
+		diagnostics.Append(m.Retention.As(ctx, &retention, basetypes.ObjectAsOptions{})...)
+		config.KeepWithin = retention.KeepWithin.ValueString()
+		config.KeepHourly = retention.KeepHourly.ValueInt64()
+		config.KeepDaily = retention.KeepDaily.ValueInt64()
+		config.KeepWeekly = retention.KeepWeekly.ValueInt64()
+		config.KeepMonthly = retention.KeepMonthly.ValueInt64()
+		config.KeepYearly = retention.KeepYearly.ValueInt64()
+	}
+	if diagnostics.HasError() {
+		return diagnostics
+	}
+
+	rendered, err := yaml.Marshal(config)
+	if err != nil {
+		diagnostics.AddError("Failed to render borgmatic configuration", err.Error())
+		return diagnostics
+	}
+	m.Config = types.StringValue(string(rendered))
+	return diagnostics
+}
+
+// sshCommand returns the SSH command borg should use to connect to the
+// repo. Host keys are always checked strictly, so the BorgBase server's host
+// key must already be in known_hosts.
+func sshCommand(keyFile, knownHostsFile string) string {
+	command := []string{"ssh", "-o", "StrictHostKeyChecking=yes"}
+	if knownHostsFile != "" {
+		command = append(command, "-o", shellQuote("UserKnownHostsFile="+knownHostsFile))
+	}
+	if keyFile != "" {
+		command = append(command, "-o", "IdentitiesOnly=yes", "-i", shellQuote(keyFile))
+	}
+	return strings.Join(command, " ")
+}
+
+// shellQuote quotes a word for a POSIX shell, which borg uses to split
+// BORG_RSH, if it contains anything other than safe characters.
+func shellQuote(word string) string {
+	if word != "" && strings.Trim(word, "abcdefghijklmnopqrstuvwxyz"+
+		"ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@%+=:,./_-~") == "" {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'"'"'`) + "'"
+}
@@ -0,0 +1,221 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/gjabell/terraform-provider-borgbase/gql"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Export writes Terraform configuration for every SSH key and Borg repo in
+// the BorgBase account of the API token in the BORGBASE_API_TOKEN env var,
+// along with import blocks to adopt them into state.
+func Export(ctx context.Context, w io.Writer, version string) error {
+	apiToken, err := resolveApiToken(ctx, BorgBaseProviderModel{})
+	if err != nil {
+		return err
+	}
+	if apiToken == "" {
+		return fmt.Errorf("a BorgBase API token must be provided in the %s env var",
+			apiTokenEnvVar)
+	}
+
+	userAgent := fmt.Sprintf("terraform-provider-borgbase/%s export", version)
+	client := gql.NewClient(borgBaseApi, apiToken, userAgent)
+
+	config, err := exportConfig(ctx, client)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(config)
+	return err
+}
+
+// exportConfig returns the generated configuration for the account of the
+// given client. Repo access lists reference the exported SSH keys instead of
+// hardcoding their IDs.
+func exportConfig(ctx context.Context, client *gql.Client) ([]byte, error) {
+	var keys SshKeysPayload
+	if err := client.Query(ctx, "sshList", &keys, gql.Arguments{}); err != nil {
+		return nil, fmt.Errorf("failed to read SSH keys: %w", err)
+	}
+	var repos BorgReposPayload
+	if err := client.Query(ctx, "repoList", &repos, gql.Arguments{}); err != nil {
+		return nil, fmt.Errorf("failed to read repos: %w", err)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	sort.SliceStable(repos, func(i, j int) bool { return repos[i].Name < repos[j].Name })
+
+	file := hclwrite.NewEmptyFile()
+	body := file.Body()
+	names := exportNames{}
+
+	keyNames := make(map[string]string, len(keys))
+	for _, key := range keys {
+		name := names.next("borgbase_ssh_key", key.Name)
+		keyNames[key.Id] = name
+
+		block := body.AppendNewBlock("resource", []string{"borgbase_ssh_key", name})
+		publicKey := key.KeyData
+		if key.Comment != "" {
+			publicKey += " " + key.Comment
+		}
+		block.Body().SetAttributeValue("name", cty.StringVal(key.Name))
+		block.Body().SetAttributeValue("public_key", cty.StringVal(publicKey))
+		body.AppendNewline()
+		appendImportBlock(body, "borgbase_ssh_key", name, key.Name)
+		body.AppendNewline()
+	}
+
+	for _, repo := range repos {
+		name := names.next("borgbase_borg_repo", repo.Name)
+		block := body.AppendNewBlock("resource", []string{"borgbase_borg_repo", name})
+		appendRepoAttributes(block.Body(), repo, keyNames)
+		body.AppendNewline()
+		appendImportBlock(body, "borgbase_borg_repo", name, repo.Name)
+		body.AppendNewline()
+	}
+
+	// Every block is followed by a blank line, which isn't needed at the end.
+	config := bytes.TrimRight(hclwrite.Format(file.Bytes()), "\n")
+	return append(config, '\n'), nil
+}
+
+// appendRepoAttributes sets the configurable attributes of a repo. Key IDs
+// are replaced by references to the exported SSH key resources, keyed by ID.
+func appendRepoAttributes(
+	body *hclwrite.Body,
+	repo BorgRepoPayload,
+	keyNames map[string]string,
+) {
+	body.SetAttributeValue("name", cty.StringVal(repo.Name))
+	body.SetAttributeValue("region", cty.StringVal(repo.Region))
+	body.SetAttributeValue("alert_days", cty.NumberIntVal(int64(repo.AlertDays)))
+	body.SetAttributeValue("append_only", cty.BoolVal(repo.AppendOnly))
+	body.SetAttributeValue("borg_version", cty.StringVal(repo.BorgVersion))
+	body.SetAttributeValue("quota_enabled", cty.BoolVal(repo.QuotaEnabled))
+	if repo.QuotaEnabled {
+		body.SetAttributeValue("quota_size", cty.StringVal(formatSize(int64(repo.Quota))))
+	}
+	body.SetAttributeValue("sftp_enabled", cty.BoolVal(repo.SftpEnabled))
+	body.SetAttributeValue("compaction", cty.ObjectVal(map[string]cty.Value{
+		"enabled":       cty.BoolVal(repo.CompactionEnabled),
+		"hour":          cty.NumberIntVal(int64(repo.CompactionHour)),
+		"hour_timezone": cty.StringVal(repo.CompactionHourTimezone),
+		"interval":      cty.NumberIntVal(int64(repo.CompactionInterval)),
+		"interval_unit": cty.StringVal(repo.CompactionIntervalUnit),
+	}))
+
+	for _, list := range []struct {
+		attribute string
+		ids       []string
+	}{
+		{"full_access_keys", repo.FullAccessKeys},
+		{"append_only_keys", repo.AppendOnlyKeys},
+		{"rsync_keys", repo.RsyncKeys},
+	} {
+		if len(list.ids) == 0 {
+			continue
+		}
+		tokens := keyReferenceTokens(list.ids, keyNames)
+		// Append-only keys can only be configured for append-only repos, so
+		// keys left over from when the repo was append-only are only noted.
+		if list.attribute == "append_only_keys" && !repo.AppendOnly {
+			body.AppendUnstructuredTokens(hclwrite.Tokens{{
+				Type: hclsyntax.TokenComment,
+				Bytes: []byte(fmt.Sprintf("# append_only_keys = %s (only allowed if append_only is true)\n",
+					tokens.Bytes())),
+			}})
+			continue
+		}
+		body.SetAttributeRaw(list.attribute, tokens)
+	}
+}
+
+// keyReferenceTokens returns a list of references to the id attribute of the
+// exported SSH keys with the given IDs. IDs of keys which weren't exported
+// are kept as literals.
+func keyReferenceTokens(ids []string, keyNames map[string]string) hclwrite.Tokens {
+	sorted := append([]string(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return keyNames[sorted[i]]+sorted[i] < keyNames[sorted[j]]+sorted[j]
+	})
+
+	elements := make([]hclwrite.Tokens, 0, len(sorted))
+	for _, id := range sorted {
+		name, ok := keyNames[id]
+		if !ok {
+			elements = append(elements, hclwrite.TokensForValue(cty.StringVal(id)))
+			continue
+		}
+		elements = append(elements, hclwrite.TokensForTraversal(hcl.Traversal{
+			hcl.TraverseRoot{Name: "borgbase_ssh_key"},
+			hcl.TraverseAttr{Name: name},
+			hcl.TraverseAttr{Name: "id"},
+		}))
+	}
+	return hclwrite.TokensForTuple(elements)
+}
+
+// appendImportBlock appends an import block for the given resource, which
+// Terraform 1.5 and later use to adopt existing objects on the next apply.
+func appendImportBlock(body *hclwrite.Body, resourceType, name, id string) {
+	block := body.AppendNewBlock("import", nil)
+	block.Body().SetAttributeTraversal("to", hcl.Traversal{
+		hcl.TraverseRoot{Name: resourceType},
+		hcl.TraverseAttr{Name: name},
+	})
+	block.Body().SetAttributeValue("id", cty.StringVal(id))
+}
+
+// exportNames assigns unique Terraform resource names, keyed by resource
+// type.
+type exportNames map[string]map[string]bool
+
+// next returns a valid resource name for an object with the given BorgBase
+// name, adding a numeric suffix if the name is already taken.
+func (n exportNames) next(resourceType, name string) string {
+	base := resourceName(name)
+	if n[resourceType] == nil {
+		n[resourceType] = make(map[string]bool)
+	}
+
+	candidate := base
+	for i := 2; n[resourceType][candidate]; i++ {
+		candidate = fmt.Sprintf("%s_%d", base, i)
+	}
+	n[resourceType][candidate] = true
+	return candidate
+}
+
+// resourceName converts a BorgBase name into a Terraform resource name by
+// lowercasing it and replacing invalid characters with underscores.
+func resourceName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+
+	result := strings.Trim(b.String(), "_")
+	if result == "" {
+		return "unnamed"
+	}
+	if result[0] < 'a' || result[0] > 'z' {
+		result = "_" + result
+	}
+	return result
+}
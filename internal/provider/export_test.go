@@ -0,0 +1,247 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestExportConfig(t *testing.T) {
+	client, _ := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		switch req.Operation {
+		case "sshList":
+			return SshKeysPayload{
+				{Id: "2", Name: "web server", KeyData: "ssh-ed25519 BBBB"},
+				{Id: "1", Name: "laptop", KeyData: "ssh-ed25519 AAAA", Comment: "me@laptop"},
+			}, nil
+		case "repoList":
+			repo := BorgRepoPayload{
+				Id:                     "r1",
+				Name:                   "Web",
+				Region:                 "eu",
+				AlertDays:              7,
+				BorgVersion:            "LATEST",
+				Quota:                  1024,
+				QuotaEnabled:           true,
+				FullAccessKeys:         []string{"2", "1"},
+				AppendOnlyKeys:         []string{"9"},
+				CompactionEnabled:      true,
+				CompactionHour:         3,
+				CompactionHourTimezone: "UTC",
+				CompactionInterval:     1,
+				CompactionIntervalUnit: "weeks",
+			}
+			archive := BorgRepoPayload{
+				Id:             "r2",
+				Name:           "Archive",
+				Region:         "us",
+				AppendOnly:     true,
+				BorgVersion:    "1.2",
+				AppendOnlyKeys: []string{"1"},
+			}
+			return BorgReposPayload{repo, archive}, nil
+		}
+		return nil, fmt.Errorf("unexpected operation %s", req.Operation)
+	})
+
+	config, err := exportConfig(context.Background(), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `resource "borgbase_ssh_key" "laptop" {
+  name       = "laptop"
+  public_key = "ssh-ed25519 AAAA me@laptop"
+}
+
+import {
+  to = borgbase_ssh_key.laptop
+  id = "laptop"
+}
+
+resource "borgbase_ssh_key" "web_server" {
+  name       = "web server"
+  public_key = "ssh-ed25519 BBBB"
+}
+
+import {
+  to = borgbase_ssh_key.web_server
+  id = "web server"
+}
+
+resource "borgbase_borg_repo" "archive" {
+  name          = "Archive"
+  region        = "us"
+  alert_days    = 0
+  append_only   = true
+  borg_version  = "1.2"
+  quota_enabled = false
+  sftp_enabled  = false
+  compaction = {
+    enabled       = false
+    hour          = 0
+    hour_timezone = ""
+    interval      = 0
+    interval_unit = ""
+  }
+  append_only_keys = [borgbase_ssh_key.laptop.id]
+}
+
+import {
+  to = borgbase_borg_repo.archive
+  id = "Archive"
+}
+
+resource "borgbase_borg_repo" "web" {
+  name          = "Web"
+  region        = "eu"
+  alert_days    = 7
+  append_only   = false
+  borg_version  = "LATEST"
+  quota_enabled = true
+  quota_size    = "1GiB"
+  sftp_enabled  = false
+  compaction = {
+    enabled       = true
+    hour          = 3
+    hour_timezone = "UTC"
+    interval      = 1
+    interval_unit = "weeks"
+  }
+  full_access_keys = [borgbase_ssh_key.laptop.id, borgbase_ssh_key.web_server.id]
+  # append_only_keys = ["9"] (only allowed if append_only is true)
+}
+
+import {
+  to = borgbase_borg_repo.web
+  id = "Web"
+}
+`
+	if string(config) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, config)
+	}
+
+	if _, diags := hclwrite.ParseConfig(config, "export.tf", hcl.InitialPos); diags.HasErrors() {
+		t.Errorf("generated invalid configuration: %s", diags)
+	}
+	testValidateExportedRepos(t, config)
+}
+
+// testValidateExportedRepos checks that the exported repos pass the borg repo
+// resource's ValidateConfig. References to the exported SSH keys are unknown,
+// as they would be when planning the import.
+func testValidateExportedRepos(t *testing.T, config []byte) {
+	t.Helper()
+	ctx := context.Background()
+
+	file, diags := hclsyntax.ParseConfig(config, "export.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	keys := make(map[string]cty.Value)
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if block.Type == "resource" && block.Labels[0] == "borgbase_ssh_key" {
+			keys[block.Labels[1]] = cty.ObjectVal(map[string]cty.Value{
+				"id": cty.UnknownVal(cty.String),
+			})
+		}
+	}
+	evalContext := &hcl.EvalContext{Variables: map[string]cty.Value{
+		"borgbase_ssh_key": cty.ObjectVal(keys),
+	}}
+
+	r := &BorgRepoResource{}
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "resource" || block.Labels[0] != "borgbase_borg_repo" {
+			continue
+		}
+
+		values := make(map[string]tftypes.Value)
+		for name, attribute := range block.Body.Attributes {
+			value, diags := attribute.Expr.Value(evalContext)
+			if diags.HasErrors() {
+				t.Fatal(diags)
+			}
+			values[name] = testCtyValue(t, objectType.AttributeTypes[name], value)
+		}
+		config := tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw:    testObjectValue(t, objectType, values),
+		}
+
+		var resp fwresource.ValidateConfigResponse
+		r.ValidateConfig(ctx, fwresource.ValidateConfigRequest{Config: config}, &resp)
+		if resp.Diagnostics.HasError() {
+			t.Errorf("%s: %s", block.Labels[1], resp.Diagnostics)
+		}
+	}
+}
+
+// testCtyValue converts an HCL value to a Terraform value of the given type.
+func testCtyValue(t *testing.T, typ tftypes.Type, value cty.Value) tftypes.Value {
+	t.Helper()
+
+	switch {
+	case !value.IsKnown():
+		return tftypes.NewValue(typ, tftypes.UnknownValue)
+	case value.IsNull():
+		return tftypes.NewValue(typ, nil)
+	case typ.Is(tftypes.String):
+		return tftypes.NewValue(typ, value.AsString())
+	case typ.Is(tftypes.Number):
+		return tftypes.NewValue(typ, value.AsBigFloat())
+	case typ.Is(tftypes.Bool):
+		return tftypes.NewValue(typ, value.True())
+	case typ.Is(tftypes.Set{}):
+		var elements []tftypes.Value
+		for it := value.ElementIterator(); it.Next(); {
+			_, element := it.Element()
+			elements = append(elements, testCtyValue(t, typ.(tftypes.Set).ElementType, element))
+		}
+		return tftypes.NewValue(typ, elements)
+	case typ.Is(tftypes.Object{}):
+		values := make(map[string]tftypes.Value)
+		for name, element := range value.AsValueMap() {
+			values[name] = testCtyValue(t, typ.(tftypes.Object).AttributeTypes[name], element)
+		}
+		return testObjectValue(t, typ.(tftypes.Object), values)
+	default:
+		t.Fatalf("unsupported type %s", typ)
+		return tftypes.Value{}
+	}
+}
+
+func TestExportNames(t *testing.T) {
+	names := exportNames{}
+	for _, tc := range []struct {
+		resourceType string
+		name         string
+		expected     string
+	}{
+		{"borgbase_ssh_key", "laptop", "laptop"},
+		{"borgbase_ssh_key", "Laptop", "laptop_2"},
+		{"borgbase_ssh_key", "laptop", "laptop_3"},
+		{"borgbase_borg_repo", "laptop", "laptop"},
+		{"borgbase_borg_repo", "web.example.com", "web_example_com"},
+		{"borgbase_borg_repo", "my-repo", "my-repo"},
+		{"borgbase_borg_repo", "2023 backups", "_2023_backups"},
+		{"borgbase_borg_repo", "***", "unnamed"},
+	} {
+		if actual := names.next(tc.resourceType, tc.name); actual != tc.expected {
+			t.Errorf("%s %q: expected %q, got %q", tc.resourceType, tc.name, tc.expected, actual)
+		}
+	}
+}
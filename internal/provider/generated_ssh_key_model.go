@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type GeneratedSshKeyModel struct {
+	AddedAt           types.String `tfsdk:"added_at"`
+	Bits              types.Int64  `tfsdk:"bits"`
+	Comment           types.String `tfsdk:"comment"`
+	GeneratedAt       types.String `tfsdk:"generated_at"`
+	HashMd5           types.String `tfsdk:"hash_md5"`
+	HashSha256        types.String `tfsdk:"hash_sha256"`
+	Id                types.String `tfsdk:"id"`
+	KeyType           types.String `tfsdk:"key_type"`
+	LastUsedAt        types.String `tfsdk:"last_used_at"`
+	Name              types.String `tfsdk:"name"`
+	PrivateKeyOpenssh types.String `tfsdk:"private_key_openssh"`
+	PublicKey         types.String `tfsdk:"public_key"`
+	Rotation          types.Object `tfsdk:"rotation"`
+	RotationDue       types.Bool   `tfsdk:"rotation_due"`
+	Timeouts          types.Object `tfsdk:"timeouts"`
+	Type              types.String `tfsdk:"type"`
+}
+
+// update sets the attributes reported by BorgBase. The keypair itself only
+// exists in the state, so it's left unchanged.
+func (m *GeneratedSshKeyModel) update(key SshKeyPayload) {
+	m.AddedAt = types.StringValue(key.AddedAt)
+	m.Bits = types.Int64Value(int64(key.Bits))
+	m.HashMd5 = types.StringValue(key.HashMd5)
+	m.HashSha256 = types.StringValue(key.HashSha256)
+	m.Id = types.StringValue(key.Id)
+	m.LastUsedAt = types.StringValue(key.LastUsedAt)
+	m.Name = types.StringValue(key.Name)
+	m.Type = types.StringValue(key.KeyType)
+}
+
+// setKeypair sets the attributes describing a newly generated keypair.
+func (m *GeneratedSshKeyModel) setKeypair(privateKey, publicKey string, generatedAt time.Time) {
+	m.GeneratedAt = types.StringValue(generatedAt.UTC().Format(time.RFC3339))
+	m.PrivateKeyOpenssh = types.StringValue(privateKey)
+	m.PublicKey = types.StringValue(publicKey)
+	m.RotationDue = types.BoolValue(false)
+}
+
+// planRotation marks the attributes which change when the keypair is
+// replaced as unknown.
+func (m *GeneratedSshKeyModel) planRotation() {
+	m.AddedAt = types.StringUnknown()
+	m.Bits = types.Int64Unknown()
+	m.GeneratedAt = types.StringUnknown()
+	m.HashMd5 = types.StringUnknown()
+	m.HashSha256 = types.StringUnknown()
+	m.Id = types.StringUnknown()
+	m.LastUsedAt = types.StringUnknown()
+	m.PrivateKeyOpenssh = types.StringUnknown()
+	m.PublicKey = types.StringUnknown()
+	m.RotationDue = types.BoolValue(false)
+	m.Type = types.StringUnknown()
+}
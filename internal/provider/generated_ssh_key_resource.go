@@ -0,0 +1,493 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gjabell/terraform-provider-borgbase/gql"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &GeneratedSshKeyResource{}
+var _ resource.ResourceWithModifyPlan = &GeneratedSshKeyResource{}
+
+func NewGeneratedSshKeyResource() resource.Resource {
+	return &GeneratedSshKeyResource{}
+}
+
+type GeneratedSshKeyResource struct {
+	client *gql.Client
+}
+
+func (r *GeneratedSshKeyResource) Metadata(
+	ctx context.Context,
+	req resource.MetadataRequest,
+	resp *resource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_generated_ssh_key"
+}
+
+func (r *GeneratedSshKeyResource) Schema(
+	ctx context.Context,
+	req resource.SchemaRequest,
+	resp *resource.SchemaResponse,
+) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "SSH keypair generated by Terraform, whose public key is added to BorgBase. " +
+			"The private key is stored unencrypted in the Terraform state.",
+		Attributes: map[string]schema.Attribute{
+			"added_at": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Date when the key was added to BorgBase.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"bits": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Number of bits in the key.",
+				PlanModifiers: []planmodifier.Int64{
+					int64planmodifier.UseStateForUnknown(),
+				},
+			},
+			"comment": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Comment appended to the public key, e.g. `backup@host`. Changing it generates a new key.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"generated_at": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Date when the keypair was generated, in RFC 3339 format.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"hash_md5": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "MD5 hash of the SSH key.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"hash_sha256": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "SHA256 hash of the SSH key.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Internal BorgBase key identifier.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"key_type": schema.StringAttribute{
+				Computed:            true,
+				Optional:            true,
+				Default:             stringdefault.StaticString("ed25519"),
+				MarkdownDescription: "Type of key to generate: ed25519, ecdsa (P-256) or rsa (3072 bits). Defaults to ed25519. Changing it generates a new key.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.OneOf(generatedKeyTypes...),
+				},
+			},
+			"last_used_at": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Date when the key was last used to access BorgBase.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"name": schema.StringAttribute{
+				MarkdownDescription: "User-defined key identifier.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"private_key_openssh": schema.StringAttribute{
+				Computed:            true,
+				Sensitive:           true,
+				MarkdownDescription: "Unencrypted private key in OpenSSH PEM format.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"public_key": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Public SSH key in authorized_keys format.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"rotation": schema.SingleNestedAttribute{
+				Optional: true,
+				MarkdownDescription: "Settings for rotating the keypair. A rotation adds a new key to BorgBase, " +
+					"replaces the old key with it in the key lists of every repository, and only then deletes the old key. " +
+					"BorgBase accepts duplicate key names, so both keys have the same name until the old key is deleted.",
+				Attributes: map[string]schema.Attribute{
+					"rotate_after": schema.StringAttribute{
+						Optional:            true,
+						MarkdownDescription: "Rotate the keypair once it is older than this period, such as `90d`, `2w` or `12h`. The age is checked when Terraform refreshes the key, against the period of the last apply.",
+						Validators: []validator.String{
+							rotationPeriodValidator{},
+						},
+					},
+					"trigger": schema.StringAttribute{
+						Optional:            true,
+						MarkdownDescription: "Arbitrary value which rotates the keypair whenever it changes. Setting the first value or removing it doesn't rotate the keypair.",
+					},
+				},
+			},
+			"rotation_due": schema.BoolAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether the keypair was older than `rotation.rotate_after` when it was last refreshed, so that the next apply rotates it.",
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"type": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Type of the SSH key.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeoutsBlock(),
+		},
+	}
+}
+
+func (r *GeneratedSshKeyResource) Configure(
+	ctx context.Context,
+	req resource.ConfigureRequest,
+	resp *resource.ConfigureResponse,
+) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*BorgBaseResourceData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider.BorgBaseResourceData, got: %T. "+
+				"Please report this issue to the provider developers.",
+				req.ProviderData),
+		)
+		return
+	}
+	r.client = data.Client
+}
+
+func (r *GeneratedSshKeyResource) Create(
+	ctx context.Context,
+	req resource.CreateRequest,
+	resp *resource.CreateResponse,
+) {
+	var data GeneratedSshKeyModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diagnostics := withTimeout(ctx, data.Timeouts, "create")
+	resp.Diagnostics.Append(diagnostics...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	defer cancel()
+
+	privateKey, publicKey, err := generateSshKey(
+		data.KeyType.ValueString(),
+		data.Comment.ValueString(),
+	)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to generate SSH key", err.Error())
+		return
+	}
+	data.setKeypair(privateKey, publicKey, timeNow())
+
+	var payload SshAddPayload
+	args := gql.Arguments{
+		"name":    gql.Optional(data.Name.ValueString()),
+		"keyData": gql.Optional(publicKey),
+	}
+	if err := r.client.Mutation(ctx, "sshAdd", &payload, args); err != nil {
+		addClientError(&resp.Diagnostics, "Failed to create SSH key", err)
+		return
+	}
+	data.update(payload.KeyAdded)
+
+	tflog.Trace(ctx, "created generated SSH key", map[string]interface{}{
+		"id":         data.Id,
+		"name":       data.Name,
+		"public_key": data.PublicKey,
+	})
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *GeneratedSshKeyResource) Read(
+	ctx context.Context,
+	req resource.ReadRequest,
+	resp *resource.ReadResponse,
+) {
+	var data GeneratedSshKeyModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diagnostics := withTimeout(ctx, data.Timeouts, "read")
+	resp.Diagnostics.Append(diagnostics...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	defer cancel()
+
+	// Keys are read by ID, since a rotation briefly adds a second key with
+	// the same name.
+	key, err := findSshKeyById(ctx, r.client, data.Id.ValueString())
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Failed to read SSH key", err)
+		return
+	}
+	// The private key can't be recovered, so a key deleted outside of
+	// Terraform is generated again.
+	if key == nil {
+		tflog.Warn(ctx, "generated SSH key no longer exists, removing from state", map[string]interface{}{
+			"id":   data.Id,
+			"name": data.Name,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	data.update(*key)
+
+	// The age is only checked here, as planning must give the same result
+	// when the plan is applied later.
+	data.RotationDue = types.BoolValue(rotationDue(data, timeNow()))
+
+	tflog.Trace(ctx, "read generated SSH key", map[string]interface{}{
+		"id":         data.Id,
+		"name":       data.Name,
+		"public_key": data.PublicKey,
+	})
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *GeneratedSshKeyResource) Update(
+	ctx context.Context,
+	req resource.UpdateRequest,
+	resp *resource.UpdateResponse,
+) {
+	var data GeneratedSshKeyModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state GeneratedSshKeyModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Changing any other attribute replaces the key, so unless ModifyPlan
+	// planned a rotation only the rotation settings and timeouts can have
+	// changed.
+	if data.Id.IsUnknown() {
+		ctx, cancel, diagnostics := withTimeout(ctx, data.Timeouts, "update")
+		resp.Diagnostics.Append(diagnostics...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		defer cancel()
+
+		r.rotate(ctx, state, &data, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// rotate replaces the key in state with a new keypair, swapping it into the
+// key lists of every repo which grants the old key access before deleting
+// the old key. If the repos can't be updated, the changes are rolled back so
+// that the old key stays in use.
+func (r *GeneratedSshKeyResource) rotate(
+	ctx context.Context,
+	state GeneratedSshKeyModel,
+	data *GeneratedSshKeyModel,
+	diagnostics *diag.Diagnostics,
+) {
+	privateKey, publicKey, err := generateSshKey(
+		data.KeyType.ValueString(),
+		data.Comment.ValueString(),
+	)
+	if err != nil {
+		diagnostics.AddError("Failed to generate SSH key", err.Error())
+		return
+	}
+
+	// The new key is added under the same name, so both keys share it until
+	// the old key is deleted.
+	var payload SshAddPayload
+	args := gql.Arguments{
+		"name":    gql.Optional(data.Name.ValueString()),
+		"keyData": gql.Optional(publicKey),
+	}
+	if err := r.client.Mutation(ctx, "sshAdd", &payload, args); err != nil {
+		addClientError(diagnostics, "Failed to add rotated SSH key", err)
+		return
+	}
+	oldId, newId := state.Id.ValueString(), payload.KeyAdded.Id
+
+	if err := replaceRepoKey(ctx, r.client, oldId, newId); err != nil {
+		detail := err.Error()
+		if err := replaceRepoKey(ctx, r.client, newId, oldId); err != nil {
+			detail += fmt.Sprintf("\n\nFailed to restore the previous key %q in borg repos: %s", oldId, err)
+		}
+		args := gql.Arguments{"id": gql.Required(newId)}
+		if err := r.client.Mutation(ctx, "sshDelete", &SshDeletePayload{}, args); err != nil {
+			detail += fmt.Sprintf("\n\nFailed to delete the new key %q: %s", newId, err)
+		}
+		addClientError(diagnostics, "Failed to rotate SSH key", errors.New(detail))
+		return
+	}
+
+	data.update(payload.KeyAdded)
+	data.setKeypair(privateKey, publicKey, timeNow())
+
+	// The new key is in use at this point, so failing to delete the old one
+	// leaves it unused rather than failing the rotation.
+	args = gql.Arguments{"id": gql.Required(oldId)}
+	if err := r.client.Mutation(ctx, "sshDelete", &SshDeletePayload{}, args); err != nil {
+		diagnostics.AddWarning(
+			"Failed to delete rotated SSH key",
+			fmt.Sprintf("The previous key %q no longer has access to any borg repos, "+
+				"but could not be deleted and should be deleted manually: %s", oldId, err),
+		)
+	}
+
+	tflog.Trace(ctx, "rotated generated SSH key", map[string]interface{}{
+		"old_id":     oldId,
+		"id":         data.Id,
+		"name":       data.Name,
+		"public_key": data.PublicKey,
+	})
+}
+
+func (r *GeneratedSshKeyResource) ModifyPlan(
+	ctx context.Context,
+	req resource.ModifyPlanRequest,
+	resp *resource.ModifyPlanResponse,
+) {
+	// Only existing keys which are kept can be rotated.
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var data GeneratedSshKeyModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state GeneratedSshKeyModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var rotation RotationModel
+	if !data.Rotation.IsNull() && !data.Rotation.IsUnknown() {
+		resp.Diagnostics.Append(
+			data.Rotation.As(ctx, &rotation, basetypes.ObjectAsOptions{})...,
+		)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	reason := rotationReason(state, rotation)
+	if reason == "" {
+		// A rotation which is no longer configured isn't due any more.
+		if state.RotationDue.ValueBool() {
+			data.RotationDue = types.BoolValue(false)
+			resp.Diagnostics.Append(resp.Plan.Set(ctx, &data)...)
+		}
+		return
+	}
+	tflog.Info(ctx, "planning rotation of generated SSH key", map[string]interface{}{
+		"id":     state.Id,
+		"name":   state.Name,
+		"reason": reason,
+	})
+
+	data.planRotation()
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &data)...)
+}
+
+func (r *GeneratedSshKeyResource) Delete(
+	ctx context.Context,
+	req resource.DeleteRequest,
+	resp *resource.DeleteResponse,
+) {
+	var data GeneratedSshKeyModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diagnostics := withTimeout(ctx, data.Timeouts, "delete")
+	resp.Diagnostics.Append(diagnostics...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	defer cancel()
+
+	args := gql.Arguments{"id": gql.Required(data.Id.ValueString())}
+	if err := r.client.Mutation(ctx, "sshDelete", &SshDeletePayload{}, args); err != nil {
+		addClientError(&resp.Diagnostics, "Failed to delete SSH key", err)
+	}
+
+	tflog.Trace(ctx, "deleted generated SSH key", map[string]interface{}{
+		"id":         data.Id,
+		"name":       data.Name,
+		"public_key": data.PublicKey,
+	})
+}
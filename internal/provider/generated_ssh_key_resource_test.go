@@ -0,0 +1,412 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestGeneratedSshKeyResourceCreate(t *testing.T) {
+	ctx := context.Background()
+
+	client, api := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		if req.Operation != "sshAdd" {
+			return nil, fmt.Errorf("unexpected operation %s", req.Operation)
+		}
+		return SshAddPayload{KeyAdded: SshKeyPayload{
+			Id:      "1",
+			Name:    "ci-runner",
+			KeyType: "ecdsa-sha2-nistp256",
+			Bits:    256,
+		}}, nil
+	})
+
+	r := &GeneratedSshKeyResource{client: client}
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+
+	raw := testObjectValue(t, objectType, map[string]tftypes.Value{
+		"comment":  tftypes.NewValue(tftypes.String, "ci@runner"),
+		"key_type": tftypes.NewValue(tftypes.String, "ecdsa"),
+		"name":     tftypes.NewValue(tftypes.String, "ci-runner"),
+	})
+	resp := resource.CreateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)},
+	}
+	r.Create(ctx, resource.CreateRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+	}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+
+	var data GeneratedSshKeyModel
+	if diagnostics := resp.State.Get(ctx, &data); diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+	if data.Id.ValueString() != "1" {
+		t.Errorf("id: expected 1, got %s", data.Id)
+	}
+
+	// The uploaded public key must belong to the private key in the state.
+	requests := api.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 operation, got %d", len(requests))
+	}
+	if uploaded := requests[0].Variables["keyData"]; uploaded != data.PublicKey.ValueString() {
+		t.Errorf("keyData: expected %s, got %v", data.PublicKey, uploaded)
+	}
+
+	signer, err := ssh.ParsePrivateKey([]byte(data.PrivateKeyOpenssh.ValueString()))
+	if err != nil {
+		t.Fatalf("failed to parse private key: %s", err)
+	}
+	expected := string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+	expected = expected[:len(expected)-1] + " ci@runner"
+	if data.PublicKey.ValueString() != expected {
+		t.Errorf("public_key: expected %s, got %s", expected, data.PublicKey)
+	}
+	if signer.PublicKey().Type() != ssh.KeyAlgoECDSA256 {
+		t.Errorf("expected %s key, got %s", ssh.KeyAlgoECDSA256, signer.PublicKey().Type())
+	}
+}
+
+func TestGeneratedSshKeyResourceRead_deleted(t *testing.T) {
+	ctx := context.Background()
+
+	client, _ := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		if req.Operation != "sshList" {
+			return nil, fmt.Errorf("unexpected operation %s", req.Operation)
+		}
+		return SshKeysPayload{}, nil
+	})
+
+	r := &GeneratedSshKeyResource{client: client}
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+
+	state := testStateFixture(t, "generated_ssh_key", 0, schemaResp.Schema)
+	resp := resource.ReadResponse{State: state}
+	r.Read(ctx, resource.ReadRequest{State: state}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+	if !resp.State.Raw.IsNull() {
+		t.Errorf("expected key to be removed from state, got %s", resp.State.Raw)
+	}
+}
+
+func TestGeneratedSshKeyResourceRead_duplicateName(t *testing.T) {
+	ctx := context.Background()
+
+	// A rotation briefly leaves two keys with the same name.
+	client, _ := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		if req.Operation != "sshList" {
+			return nil, fmt.Errorf("unexpected operation %s", req.Operation)
+		}
+		return SshKeysPayload{
+			{Id: "3", Name: "terraform_test", KeyType: "ssh-ed25519", Bits: 256},
+			{Id: "1", Name: "terraform_test", KeyType: "ssh-ed25519", Bits: 256, Comment: "backup@host"},
+		}, nil
+	})
+
+	r := &GeneratedSshKeyResource{client: client}
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+
+	state := testStateFixture(t, "generated_ssh_key", 0, schemaResp.Schema)
+	resp := resource.ReadResponse{State: state}
+	r.Read(ctx, resource.ReadRequest{State: state}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+
+	var data GeneratedSshKeyModel
+	if diagnostics := resp.State.Get(ctx, &data); diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+	if data.Id.ValueString() != "1" {
+		t.Errorf("id: expected 1, got %s", data.Id)
+	}
+}
+
+// testGeneratedSshKeyPlan returns the generated_ssh_key state fixture and a
+// plan which changes it with the given function.
+func testGeneratedSshKeyPlan(
+	t *testing.T,
+	r resource.Resource,
+	change func(*GeneratedSshKeyModel),
+) (tfsdk.State, tfsdk.Plan) {
+	t.Helper()
+	ctx := context.Background()
+
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	state := testStateFixture(t, "generated_ssh_key", 0, schemaResp.Schema)
+
+	var data GeneratedSshKeyModel
+	if diagnostics := state.Get(ctx, &data); diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+	change(&data)
+	plan := tfsdk.Plan{Schema: schemaResp.Schema, Raw: state.Raw}
+	if diagnostics := plan.Set(ctx, &data); diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+	return state, plan
+}
+
+func TestGeneratedSshKeyResourceModifyPlan_rotation(t *testing.T) {
+	ctx := context.Background()
+	// Planning doesn't depend on the time, so that applying a plan later
+	// gives the same result.
+	defer func(now func() time.Time) { timeNow = now }(timeNow)
+	timeNow = func() time.Time { panic("unexpected call to timeNow") }
+
+	for name, tc := range map[string]struct {
+		due         bool
+		rotation    types.Object
+		rotated     bool
+		expectedDue bool
+	}{
+		"not due": {
+			rotation: testRotation(types.StringValue("90d"), types.StringValue("1")),
+		},
+		"due": {
+			due:      true,
+			rotation: testRotation(types.StringValue("90d"), types.StringValue("1")),
+			rotated:  true,
+		},
+		"due, rotate_after removed": {
+			due:      true,
+			rotation: testRotation(types.StringNull(), types.StringValue("1")),
+		},
+		"trigger changed": {
+			rotation: testRotation(types.StringNull(), types.StringValue("2")),
+			rotated:  true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := NewGeneratedSshKeyResource().(resource.ResourceWithModifyPlan)
+			state, plan := testGeneratedSshKeyPlan(t, r, func(m *GeneratedSshKeyModel) {
+				m.Rotation = tc.rotation
+				m.RotationDue = types.BoolValue(tc.due)
+			})
+			prior := testRotation(types.StringValue("90d"), types.StringValue("1"))
+			if diagnostics := state.SetAttribute(ctx, path.Root("rotation"), prior); diagnostics.HasError() {
+				t.Fatal(diagnostics)
+			}
+			if diagnostics := state.SetAttribute(ctx, path.Root("rotation_due"), tc.due); diagnostics.HasError() {
+				t.Fatal(diagnostics)
+			}
+
+			resp := resource.ModifyPlanResponse{Plan: plan}
+			r.ModifyPlan(ctx, resource.ModifyPlanRequest{State: state, Plan: plan}, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatal(resp.Diagnostics)
+			}
+
+			var data GeneratedSshKeyModel
+			if diagnostics := resp.Plan.Get(ctx, &data); diagnostics.HasError() {
+				t.Fatal(diagnostics)
+			}
+			if data.Id.IsUnknown() != tc.rotated || data.PrivateKeyOpenssh.IsUnknown() != tc.rotated {
+				t.Errorf("expected rotated %t, got id %s", tc.rotated, data.Id)
+			}
+			if !data.RotationDue.Equal(types.BoolValue(tc.expectedDue)) {
+				t.Errorf("expected rotation_due %t, got %s", tc.expectedDue, data.RotationDue)
+			}
+		})
+	}
+}
+
+func TestGeneratedSshKeyResourceRead_rotationDue(t *testing.T) {
+	ctx := context.Background()
+	defer func(now func() time.Time) { timeNow = now }(timeNow)
+	timeNow = func() time.Time { return time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC) }
+
+	client, _ := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		return SshKeysPayload{
+			{Id: "1", Name: "terraform_test", AddedAt: "2023-04-01T12:00:00+00:00"},
+		}, nil
+	})
+
+	for name, tc := range map[string]struct {
+		rotation types.Object
+		expected bool
+	}{
+		"not configured": {rotation: types.ObjectNull(nil)},
+		"not due":        {rotation: testRotation(types.StringValue("180d"), types.StringNull())},
+		"due":            {rotation: testRotation(types.StringValue("90d"), types.StringNull()), expected: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// The v0 fixture predates generated_at, so the key's age is
+			// taken from added_at.
+			r := &GeneratedSshKeyResource{client: client}
+			state, _ := testGeneratedSshKeyPlan(t, r, func(m *GeneratedSshKeyModel) {
+				if !m.GeneratedAt.IsNull() || !m.Rotation.IsNull() {
+					t.Fatalf("expected null generated_at and rotation, got %s, %s", m.GeneratedAt, m.Rotation)
+				}
+			})
+			if !tc.rotation.IsNull() {
+				if diagnostics := state.SetAttribute(ctx, path.Root("rotation"), tc.rotation); diagnostics.HasError() {
+					t.Fatal(diagnostics)
+				}
+			}
+
+			resp := resource.ReadResponse{State: state}
+			r.Read(ctx, resource.ReadRequest{State: state}, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatal(resp.Diagnostics)
+			}
+
+			var data GeneratedSshKeyModel
+			if diagnostics := resp.State.Get(ctx, &data); diagnostics.HasError() {
+				t.Fatal(diagnostics)
+			}
+			if !data.RotationDue.Equal(types.BoolValue(tc.expected)) {
+				t.Errorf("expected rotation_due %t, got %s", tc.expected, data.RotationDue)
+			}
+		})
+	}
+}
+
+func TestGeneratedSshKeyResourceUpdate_rotate(t *testing.T) {
+	ctx := context.Background()
+
+	repos := BorgReposPayload{
+		{Id: "a", Name: "a", FullAccessKeys: []string{"1", "2"}},
+		{Id: "b", Name: "b", AppendOnlyKeys: []string{"2"}, RsyncKeys: []string{"1"}},
+	}
+	client, api := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		switch req.Operation {
+		case "sshAdd":
+			return SshAddPayload{KeyAdded: SshKeyPayload{Id: "3", Name: "terraform_test"}}, nil
+		case "repoList":
+			return repos, nil
+		case "repoEdit", "sshDelete":
+			return map[string]interface{}{}, nil
+		default:
+			return nil, fmt.Errorf("unexpected operation %s", req.Operation)
+		}
+	})
+
+	r := &GeneratedSshKeyResource{client: client}
+	state, plan := testGeneratedSshKeyPlan(t, r, func(m *GeneratedSshKeyModel) {
+		m.planRotation()
+	})
+	resp := resource.UpdateResponse{State: state}
+	r.Update(ctx, resource.UpdateRequest{State: state, Plan: plan}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+
+	var data GeneratedSshKeyModel
+	if diagnostics := resp.State.Get(ctx, &data); diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+	if data.Id.ValueString() != "3" || data.GeneratedAt.IsUnknown() {
+		t.Errorf("expected rotated key 3, got %s generated at %s", data.Id, data.GeneratedAt)
+	}
+	if _, err := ssh.ParsePrivateKey([]byte(data.PrivateKeyOpenssh.ValueString())); err != nil {
+		t.Errorf("failed to parse private key: %s", err)
+	}
+
+	// The old key may only be deleted once no repo refers to it any more.
+	var operations []string
+	for _, req := range api.Requests() {
+		operation := req.Operation
+		switch operation {
+		case "repoEdit":
+			operation += fmt.Sprintf(" %v %v %v %v", req.Variables["id"],
+				req.Variables["fullAccessKeys"], req.Variables["appendOnlyKeys"],
+				req.Variables["rsyncKeys"])
+		case "sshDelete":
+			operation += fmt.Sprintf(" %v", req.Variables["id"])
+		}
+		operations = append(operations, operation)
+	}
+	expected := []string{
+		"sshAdd",
+		"repoList",
+		"repoList",
+		"repoEdit a [3 2] <nil> <nil>",
+		"repoList",
+		"repoEdit b <nil> <nil> [3]",
+		"sshDelete 1",
+	}
+	if !reflect.DeepEqual(operations, expected) {
+		t.Errorf("expected operations %v, got %v", expected, operations)
+	}
+
+	// The new key is added with the same name as the old key.
+	if name := api.Requests()[0].Variables["name"]; name != "terraform_test" {
+		t.Errorf("name: expected terraform_test, got %v", name)
+	}
+}
+
+func TestGeneratedSshKeyResourceUpdate_rollback(t *testing.T) {
+	ctx := context.Background()
+
+	repos := BorgReposPayload{
+		{Id: "a", Name: "a", FullAccessKeys: []string{"1"}},
+		{Id: "b", Name: "b", FullAccessKeys: []string{"1"}},
+	}
+	client, api := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		switch req.Operation {
+		case "sshAdd":
+			return SshAddPayload{KeyAdded: SshKeyPayload{Id: "3", Name: "terraform_test"}}, nil
+		case "repoList":
+			return repos, nil
+		case "repoEdit":
+			if req.Variables["id"] == "b" {
+				return nil, fmt.Errorf("repo is locked")
+			}
+			repos[0].FullAccessKeys = []string{"3"}
+			return map[string]interface{}{}, nil
+		case "sshDelete":
+			return map[string]interface{}{}, nil
+		default:
+			return nil, fmt.Errorf("unexpected operation %s", req.Operation)
+		}
+	})
+
+	r := &GeneratedSshKeyResource{client: client}
+	state, plan := testGeneratedSshKeyPlan(t, r, func(m *GeneratedSshKeyModel) {
+		m.planRotation()
+	})
+	resp := resource.UpdateResponse{State: state}
+	r.Update(ctx, resource.UpdateRequest{State: state, Plan: plan}, &resp)
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error")
+	}
+
+	// Repo a is switched back to the old key and the new key is deleted.
+	var edits, deletes []interface{}
+	for _, req := range api.Requests() {
+		switch req.Operation {
+		case "repoEdit":
+			edits = append(edits, fmt.Sprint(req.Variables["id"], req.Variables["fullAccessKeys"]))
+		case "sshDelete":
+			deletes = append(deletes, req.Variables["id"])
+		}
+	}
+	expectedEdits := []interface{}{"a[3]", "b[3]", "a[1]"}
+	if !reflect.DeepEqual(edits, expectedEdits) {
+		t.Errorf("expected edits %v, got %v", expectedEdits, edits)
+	}
+	if !reflect.DeepEqual(deletes, []interface{}{"3"}) {
+		t.Errorf("expected only the new key to be deleted, got %v", deletes)
+	}
+}
@@ -1,16 +1,24 @@
 package provider
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
+	"os/exec"
+	"strings"
 
 	"github.com/gjabell/terraform-provider-borgbase/gql"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 )
 
 const borgBaseApi = "https://api.borgbase.com/graphql"
@@ -19,6 +27,7 @@ const apiTokenEnvVar = "BORGBASE_API_TOKEN"
 
 // Ensure BorgBaseProvider satisfies various provider interfaces.
 var _ provider.Provider = &BorgBaseProvider{}
+var _ provider.ProviderWithValidateConfig = &BorgBaseProvider{}
 
 type BorgBaseProvider struct {
 	// version is set to the provider version on release, "dev" when the
@@ -28,7 +37,21 @@ type BorgBaseProvider struct {
 }
 
 type BorgBaseProviderModel struct {
-	ApiToken types.String `tfsdk:"api_token"`
+	ApiToken        types.String `tfsdk:"api_token"`
+	ApiTokenCommand types.String `tfsdk:"api_token_command"`
+	ApiTokenFile    types.String `tfsdk:"api_token_file"`
+	ExpectedAccount types.String `tfsdk:"expected_account"`
+	RepoDefaults    types.Object `tfsdk:"repo_defaults"`
+	UserAgentSuffix types.String `tfsdk:"user_agent_suffix"`
+	VerifyToken     types.Bool   `tfsdk:"verify_token_on_configure"`
+}
+
+// BorgBaseResourceData is passed to resources when the provider is
+// configured.
+type BorgBaseResourceData struct {
+	Client       *gql.Client
+	RepoDefaults RepoDefaultsModel
+	borgVersions *borgVersionCache
 }
 
 func (p *BorgBaseProvider) Metadata(
@@ -52,10 +75,158 @@ func (p *BorgBaseProvider) Schema(
 				Optional:            true,
 				Sensitive:           true,
 			},
+			"api_token_command": schema.StringAttribute{
+				MarkdownDescription: "Shell command whose output is used as the BorgBase API token " +
+					"(used if neither `api_token` nor `api_token_file` is set).",
+				Optional: true,
+			},
+			"api_token_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a file containing the BorgBase API token " +
+					"(used if `api_token` is not set).",
+				Optional: true,
+			},
+			"expected_account": schema.StringAttribute{
+				MarkdownDescription: "Email address or ID of the BorgBase account the API token " +
+					"must belong to. Configuration fails if the token belongs to another account.",
+				Optional: true,
+			},
+			"user_agent_suffix": schema.StringAttribute{
+				MarkdownDescription: "Extra text appended to the User-Agent header sent to BorgBase.",
+				Optional:            true,
+			},
+			"verify_token_on_configure": schema.BoolAttribute{
+				MarkdownDescription: "Whether to check that the API token is valid when " +
+					"configuring the provider (defaults to false).",
+				Optional: true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"repo_defaults": schema.SingleNestedBlock{
+				MarkdownDescription: "Default settings for `borgbase_borg_repo` resources, " +
+					"used for attributes which aren't set in the resource.",
+				Attributes: map[string]schema.Attribute{
+					"alert_days": schema.Int64Attribute{
+						MarkdownDescription: "Default number of days after which an alert " +
+							"should be triggered if no new backups are made.",
+						Optional: true,
+					},
+					"compaction": schema.SingleNestedAttribute{
+						MarkdownDescription: "Default settings for repository compaction.",
+						Optional:            true,
+						Attributes: map[string]schema.Attribute{
+							"enabled": schema.BoolAttribute{
+								MarkdownDescription: "Whether to enable repository compaction.",
+								Required:            true,
+							},
+							"hour": schema.Int64Attribute{
+								MarkdownDescription: "Hour of the day when repositories " +
+									"should be compacted.",
+								Required: true,
+								Validators: []validator.Int64{
+									int64validator.Between(0, 23),
+								},
+							},
+							"hour_timezone": schema.StringAttribute{
+								MarkdownDescription: "Timezone of repository compaction " +
+									"hour, as an IANA timezone name such as `Europe/Berlin`.",
+								Required: true,
+								Validators: []validator.String{
+									timezoneValidator{},
+								},
+							},
+							"interval": schema.Int64Attribute{
+								MarkdownDescription: "Repository compaction interval value (1-24).",
+								Required:            true,
+								Validators: []validator.Int64{
+									int64validator.Between(1, 24),
+								},
+							},
+							"interval_unit": schema.StringAttribute{
+								MarkdownDescription: "Repository compaction interval unit " +
+									"(days, weeks, or months).",
+								Required: true,
+								Validators: []validator.String{
+									stringvalidator.OneOf("days", "weeks", "months"),
+								},
+							},
+						},
+					},
+					"region": schema.StringAttribute{
+						MarkdownDescription: "Default region where new repositories are " +
+							"hosted (eu or us). Changing it doesn't move existing repositories.",
+						Optional: true,
+						Validators: []validator.String{
+							stringvalidator.OneOf("eu", "us"),
+						},
+					},
+				},
+			},
 		},
 	}
 }
 
+func (p *BorgBaseProvider) ValidateConfig(
+	ctx context.Context,
+	req provider.ValidateConfigRequest,
+	resp *provider.ValidateConfigResponse,
+) {
+	var data BorgBaseProviderModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for name, attr := range map[string]types.String{
+		"api_token":         data.ApiToken,
+		"api_token_command": data.ApiTokenCommand,
+		"api_token_file":    data.ApiTokenFile,
+		"expected_account":  data.ExpectedAccount,
+	} {
+		if !attr.IsNull() && !attr.IsUnknown() &&
+			strings.TrimSpace(attr.ValueString()) == "" {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(name),
+				"Invalid provider configuration",
+				fmt.Sprintf("%s must not be empty if set.", name),
+			)
+		}
+	}
+
+	// Only the first configured token source is used, so warn about any
+	// others which would be silently ignored.
+	var sources []string
+	for _, source := range []struct {
+		name string
+		attr types.String
+	}{
+		{"api_token", data.ApiToken},
+		{"api_token_file", data.ApiTokenFile},
+		{"api_token_command", data.ApiTokenCommand},
+	} {
+		if !source.attr.IsNull() {
+			sources = append(sources, source.name)
+		}
+	}
+	for i := 1; i < len(sources); i++ {
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root(sources[i]),
+			"Ignored API token source",
+			fmt.Sprintf("%s is ignored because %s is also set.",
+				sources[i], sources[0]),
+		)
+	}
+
+	if suffix := data.UserAgentSuffix; !suffix.IsNull() && !suffix.IsUnknown() &&
+		strings.ContainsAny(suffix.ValueString(), "\r\n") {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("user_agent_suffix"),
+			"Invalid provider configuration",
+			"user_agent_suffix must not contain line breaks.",
+		)
+	}
+}
+
 func (p *BorgBaseProvider) Configure(
 	ctx context.Context,
 	req provider.ConfigureRequest,
@@ -68,21 +239,112 @@ func (p *BorgBaseProvider) Configure(
 		return
 	}
 
-	apiToken := os.Getenv(apiTokenEnvVar)
-	if data.ApiToken.ValueString() != "" {
-		apiToken = data.ApiToken.ValueString()
+	apiToken, err := resolveApiToken(ctx, data)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to resolve API token", err.Error())
+		return
 	}
 
 	if apiToken == "" {
 		resp.Diagnostics.AddError("Missing API token",
 			fmt.Sprintf("A BorgBase API token must be provided in the provider "+
-				"configuration block in the api_token attribute or in the %s env var.",
+				"configuration block in the api_token, api_token_file or "+
+				"api_token_command attribute or in the %s env var.",
 				apiTokenEnvVar))
+		return
+	}
+
+	userAgent := p.userAgent(req.TerraformVersion, data.UserAgentSuffix.ValueString())
+	client := gql.NewClient(borgBaseApi, apiToken, userAgent)
+
+	expected := data.ExpectedAccount.ValueString()
+	if data.VerifyToken.ValueBool() || expected != "" {
+		var account AccountPayload
+		if err := client.Query(ctx, "me", &account, gql.Arguments{}); err != nil {
+			resp.Diagnostics.AddError("Invalid API token",
+				fmt.Sprintf("Failed to authenticate with BorgBase using the "+
+					"configured API token: %s", err))
+			return
+		}
+		if expected != "" && !account.matches(expected) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("expected_account"),
+				"Unexpected BorgBase account",
+				fmt.Sprintf("The API token belongs to account %s (%s), "+
+					"but expected_account is %q.",
+					account.Email, account.Id, expected),
+			)
+			return
+		}
+	}
+
+	resourceData := &BorgBaseResourceData{
+		Client:       client,
+		borgVersions: &borgVersionCache{},
+	}
+	if !data.RepoDefaults.IsNull() {
+		resp.Diagnostics.Append(data.RepoDefaults.As(
+			ctx,
+			&resourceData.RepoDefaults,
+			basetypes.ObjectAsOptions{UnhandledUnknownAsEmpty: true},
+		)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
 	}
 
-	client := gql.NewClient(borgBaseApi, apiToken)
 	resp.DataSourceData = client
-	resp.ResourceData = client
+	resp.ResourceData = resourceData
+}
+
+// resolveApiToken returns the first API token found in the api_token,
+// api_token_file and api_token_command attributes and the BORGBASE_API_TOKEN
+// env var, in that order. Trailing newlines are trimmed from tokens read from
+// files or commands.
+func resolveApiToken(
+	ctx context.Context,
+	data BorgBaseProviderModel,
+) (string, error) {
+	if token := data.ApiToken.ValueString(); token != "" {
+		return token, nil
+	}
+
+	if file := data.ApiTokenFile.ValueString(); file != "" {
+		token, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read api_token_file: %w", err)
+		}
+		return strings.TrimRight(string(token), "\r\n"), nil
+	}
+
+	if command := data.ApiTokenCommand.ValueString(); command != "" {
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Stderr = &stderr
+		token, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to run api_token_command: %w: %s",
+				err, strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimRight(string(token), "\r\n"), nil
+	}
+
+	return os.Getenv(apiTokenEnvVar), nil
+}
+
+// userAgent builds the User-Agent header identifying the provider and
+// Terraform versions, optionally followed by a user-defined suffix.
+func (p *BorgBaseProvider) userAgent(terraformVersion, suffix string) string {
+	if terraformVersion == "" {
+		terraformVersion = "unknown"
+	}
+
+	userAgent := fmt.Sprintf("terraform-provider-borgbase/%s terraform/%s",
+		p.version, terraformVersion)
+	if suffix = strings.TrimSpace(suffix); suffix != "" {
+		userAgent += " " + suffix
+	}
+	return userAgent
 }
 
 func (p *BorgBaseProvider) Resources(
@@ -90,6 +352,8 @@ func (p *BorgBaseProvider) Resources(
 ) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewBorgRepoResource,
+		NewGeneratedSshKeyResource,
+		NewRepoKeyAccessResource,
 		NewSshKeyResource,
 	}
 }
@@ -98,7 +362,10 @@ func (p *BorgBaseProvider) DataSources(
 	ctx context.Context,
 ) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
+		NewAccountDataSource,
+		NewBorgClientEnvDataSource,
 		NewBorgRepoDataSource,
+		NewBorgmaticConfigDataSource,
 		NewSshKeyDataSource,
 	}
 }
@@ -1,11 +1,17 @@
 package provider
 
 import (
+	"context"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 )
 
 var testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
@@ -17,3 +23,194 @@ func testAccPreCheck(t *testing.T) {
 		t.Fatalf("%s must be set", apiTokenEnvVar)
 	}
 }
+
+func TestUserAgent(t *testing.T) {
+	p := &BorgBaseProvider{version: "1.2.3"}
+
+	for _, tc := range []struct {
+		terraformVersion string
+		suffix           string
+		expected         string
+	}{
+		{"1.5.0", "", "terraform-provider-borgbase/1.2.3 terraform/1.5.0"},
+		{"", "", "terraform-provider-borgbase/1.2.3 terraform/unknown"},
+		{
+			"1.5.0",
+			" acme-ci/42 ",
+			"terraform-provider-borgbase/1.2.3 terraform/1.5.0 acme-ci/42",
+		},
+	} {
+		if actual := p.userAgent(tc.terraformVersion, tc.suffix); actual != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, actual)
+		}
+	}
+}
+
+func TestResolveApiToken(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(apiTokenEnvVar, "from-env")
+
+	for name, tc := range map[string]struct {
+		data     BorgBaseProviderModel
+		expected string
+	}{
+		"env": {
+			data:     BorgBaseProviderModel{},
+			expected: "from-env",
+		},
+		"command": {
+			data: BorgBaseProviderModel{
+				ApiTokenCommand: types.StringValue("printf 'from-command\\n\\n'"),
+			},
+			expected: "from-command",
+		},
+		"file": {
+			data: BorgBaseProviderModel{
+				ApiTokenCommand: types.StringValue("echo from-command"),
+				ApiTokenFile:    types.StringValue(file),
+			},
+			expected: "from-file",
+		},
+		"attribute": {
+			data: BorgBaseProviderModel{
+				ApiToken:        types.StringValue("from-attribute"),
+				ApiTokenCommand: types.StringValue("echo from-command"),
+				ApiTokenFile:    types.StringValue(file),
+			},
+			expected: "from-attribute",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			actual, err := resolveApiToken(context.Background(), tc.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+
+	for name, data := range map[string]BorgBaseProviderModel{
+		"missing file": {
+			ApiTokenFile: types.StringValue(filepath.Join(t.TempDir(), "missing")),
+		},
+		"failing command": {ApiTokenCommand: types.StringValue("exit 1")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := resolveApiToken(context.Background(), data); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// testObjectValue builds an object of the given type from the given
+// attribute values, leaving every other attribute null.
+func testObjectValue(
+	t *testing.T,
+	objectType tftypes.Object,
+	values map[string]tftypes.Value,
+) tftypes.Value {
+	t.Helper()
+
+	attrs := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+	for name, attrType := range objectType.AttributeTypes {
+		if v, ok := values[name]; ok {
+			attrs[name] = v
+		} else {
+			attrs[name] = tftypes.NewValue(attrType, nil)
+		}
+	}
+	for name := range values {
+		if _, ok := objectType.AttributeTypes[name]; !ok {
+			t.Fatalf("unknown attribute %s", name)
+		}
+	}
+	return tftypes.NewValue(objectType, attrs)
+}
+
+// testProviderConfig builds a provider configuration from the given
+// attribute values, leaving every other attribute null.
+func testProviderConfig(
+	t *testing.T,
+	values map[string]tftypes.Value,
+) tfsdk.Config {
+	t.Helper()
+
+	var schemaResp provider.SchemaResponse
+	New("test")().Schema(context.Background(), provider.SchemaRequest{}, &schemaResp)
+
+	objectType := schemaResp.Schema.Type().TerraformType(context.Background())
+	return tfsdk.Config{
+		Schema: schemaResp.Schema,
+		Raw:    testObjectValue(t, objectType.(tftypes.Object), values),
+	}
+}
+
+func TestProviderValidateConfig(t *testing.T) {
+	for name, tc := range map[string]struct {
+		values   map[string]tftypes.Value
+		errors   int
+		warnings int
+	}{
+		"empty": {},
+		"token": {
+			values: map[string]tftypes.Value{
+				"api_token": tftypes.NewValue(tftypes.String, "token"),
+			},
+		},
+		"unknown token": {
+			values: map[string]tftypes.Value{
+				"api_token": tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			},
+		},
+		"blank token": {
+			values: map[string]tftypes.Value{
+				"api_token": tftypes.NewValue(tftypes.String, " "),
+			},
+			errors: 1,
+		},
+		"blank expected account": {
+			values: map[string]tftypes.Value{
+				"expected_account": tftypes.NewValue(tftypes.String, ""),
+			},
+			errors: 1,
+		},
+		"multiple token sources": {
+			values: map[string]tftypes.Value{
+				"api_token":         tftypes.NewValue(tftypes.String, "token"),
+				"api_token_command": tftypes.NewValue(tftypes.String, "pass borgbase"),
+				"api_token_file":    tftypes.NewValue(tftypes.String, "/run/token"),
+			},
+			warnings: 2,
+		},
+		"multiline user agent suffix": {
+			values: map[string]tftypes.Value{
+				"user_agent_suffix": tftypes.NewValue(tftypes.String, "a\nb"),
+			},
+			errors: 1,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := New("test")().(provider.ProviderWithValidateConfig)
+			req := provider.ValidateConfigRequest{
+				Config: testProviderConfig(t, tc.values),
+			}
+			var resp provider.ValidateConfigResponse
+			p.ValidateConfig(context.Background(), req, &resp)
+
+			if errors := resp.Diagnostics.ErrorsCount(); errors != tc.errors {
+				t.Errorf("expected %d errors, got %d: %v",
+					tc.errors, errors, resp.Diagnostics)
+			}
+			if warnings := resp.Diagnostics.WarningsCount(); warnings != tc.warnings {
+				t.Errorf("expected %d warnings, got %d: %v",
+					tc.warnings, warnings, resp.Diagnostics)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// RepoDefaultsModel holds the provider's repo_defaults block.
+type RepoDefaultsModel struct {
+	AlertDays  types.Int64  `tfsdk:"alert_days"`
+	Compaction types.Object `tfsdk:"compaction"`
+	Region     types.String `tfsdk:"region"`
+}
+
+// applyRepoDefaults replaces planned values for attributes which aren't set
+// in config with the provider's defaults. The region can't be changed without
+// replacing the repository, so its default is only used for new ones.
+func (m *BorgRepoModel) applyRepoDefaults(
+	config BorgRepoModel,
+	defaults RepoDefaultsModel,
+) {
+	if config.AlertDays.IsNull() && !defaults.AlertDays.IsNull() {
+		m.AlertDays = defaults.AlertDays
+	}
+	if config.Compaction.IsNull() && !defaults.Compaction.IsNull() {
+		if defaults.Compaction.IsUnknown() {
+			m.Compaction = types.ObjectUnknown(compactionAttributes)
+		} else {
+			m.Compaction = defaults.Compaction
+		}
+	}
+	if config.Region.IsNull() && m.Region.IsUnknown() && !defaults.Region.IsNull() {
+		m.Region = defaults.Region
+	}
+}
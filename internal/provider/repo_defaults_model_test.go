@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestApplyRepoDefaults(t *testing.T) {
+	defaults := RepoDefaultsModel{
+		AlertDays:  types.Int64Value(7),
+		Compaction: testCompaction(types.BoolValue(true)),
+		Region:     types.StringValue("eu"),
+	}
+
+	for name, tc := range map[string]struct {
+		config   func(*BorgRepoModel)
+		plan     func(*BorgRepoModel)
+		defaults RepoDefaultsModel
+		expected func(*BorgRepoModel)
+	}{
+		"inherited": {
+			plan: func(m *BorgRepoModel) {
+				m.AlertDays = types.Int64Unknown()
+				m.Compaction = types.ObjectUnknown(compactionAttributes)
+				m.Region = types.StringUnknown()
+			},
+			defaults: defaults,
+			expected: func(m *BorgRepoModel) {
+				m.AlertDays = types.Int64Value(7)
+				m.Compaction = testCompaction(types.BoolValue(true))
+				m.Region = types.StringValue("eu")
+			},
+		},
+		"configured": {
+			config: func(m *BorgRepoModel) {
+				m.AlertDays = types.Int64Value(3)
+				m.Compaction = testCompaction(types.BoolValue(false))
+				m.Region = types.StringValue("us")
+			},
+			plan: func(m *BorgRepoModel) {
+				m.AlertDays = types.Int64Value(3)
+				m.Compaction = testCompaction(types.BoolValue(false))
+				m.Region = types.StringValue("us")
+			},
+			defaults: defaults,
+			expected: func(m *BorgRepoModel) {
+				m.AlertDays = types.Int64Value(3)
+				m.Compaction = testCompaction(types.BoolValue(false))
+				m.Region = types.StringValue("us")
+			},
+		},
+		"existing region": {
+			plan:     func(m *BorgRepoModel) { m.Region = types.StringValue("us") },
+			defaults: defaults,
+			expected: func(m *BorgRepoModel) {
+				m.AlertDays = types.Int64Value(7)
+				m.Compaction = testCompaction(types.BoolValue(true))
+				m.Region = types.StringValue("us")
+			},
+		},
+		"no defaults": {
+			plan: func(m *BorgRepoModel) {
+				m.AlertDays = types.Int64Value(3)
+				m.Region = types.StringUnknown()
+			},
+			expected: func(m *BorgRepoModel) {
+				m.AlertDays = types.Int64Value(3)
+				m.Region = types.StringUnknown()
+			},
+		},
+		"unknown defaults": {
+			plan: func(m *BorgRepoModel) { m.Compaction = testCompaction(types.BoolValue(false)) },
+			defaults: RepoDefaultsModel{
+				AlertDays:  types.Int64Unknown(),
+				Compaction: types.ObjectUnknown(compactionAttributes),
+			},
+			expected: func(m *BorgRepoModel) {
+				m.AlertDays = types.Int64Unknown()
+				m.Compaction = types.ObjectUnknown(compactionAttributes)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			config, plan, expected := testNullBorgRepoModel(),
+				testNullBorgRepoModel(), testNullBorgRepoModel()
+			if tc.config != nil {
+				tc.config(&config)
+			}
+			tc.plan(&plan)
+			tc.expected(&expected)
+
+			plan.applyRepoDefaults(config, tc.defaults)
+			if !plan.AlertDays.Equal(expected.AlertDays) {
+				t.Errorf("alert_days: expected %s, got %s", expected.AlertDays, plan.AlertDays)
+			}
+			if !plan.Compaction.Equal(expected.Compaction) {
+				t.Errorf("compaction: expected %s, got %s", expected.Compaction, plan.Compaction)
+			}
+			if !plan.Region.Equal(expected.Region) {
+				t.Errorf("region: expected %s, got %s", expected.Region, plan.Region)
+			}
+		})
+	}
+}
+
+func TestBorgRepoResourceModifyPlan_missingRegion(t *testing.T) {
+	ctx := context.Background()
+
+	config := testBorgRepoConfig(t, map[string]tftypes.Value{
+		"name": tftypes.NewValue(tftypes.String, "test"),
+	})
+	plan := tfsdk.Plan{
+		Schema: config.Schema,
+		Raw: testObjectValue(t, config.Raw.Type().(tftypes.Object), map[string]tftypes.Value{
+			"name":   tftypes.NewValue(tftypes.String, "test"),
+			"region": tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+		}),
+	}
+
+	for name, tc := range map[string]struct {
+		defaults      RepoDefaultsModel
+		expectedError bool
+	}{
+		"without default": {expectedError: true},
+		"with default":    {defaults: RepoDefaultsModel{Region: types.StringValue("us")}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := &BorgRepoResource{repoDefaults: tc.defaults}
+			req := fwresource.ModifyPlanRequest{Config: config, Plan: plan}
+			resp := fwresource.ModifyPlanResponse{Plan: plan}
+			r.ModifyPlan(ctx, req, &resp)
+			if resp.Diagnostics.HasError() != tc.expectedError {
+				t.Fatalf("expected error %t, got %s", tc.expectedError, resp.Diagnostics)
+			}
+			if tc.expectedError {
+				return
+			}
+
+			var data BorgRepoModel
+			if diagnostics := resp.Plan.Get(ctx, &data); diagnostics.HasError() {
+				t.Fatal(diagnostics)
+			}
+			if !data.Region.Equal(tc.defaults.Region) {
+				t.Errorf("region: expected %s, got %s", tc.defaults.Region, data.Region)
+			}
+		})
+	}
+}
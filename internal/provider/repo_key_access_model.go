@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gjabell/terraform-provider-borgbase/gql"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type RepoKeyAccessModel struct {
+	Access   types.String `tfsdk:"access"`
+	Id       types.String `tfsdk:"id"`
+	KeyId    types.String `tfsdk:"key_id"`
+	RepoId   types.String `tfsdk:"repo_id"`
+	Timeouts types.Object `tfsdk:"timeouts"`
+}
+
+// keyAccessLevels maps each access level to the repoEdit argument holding
+// the keys granted that level.
+var keyAccessLevels = map[string]string{
+	"append_only": "appendOnlyKeys",
+	"full":        "fullAccessKeys",
+	"rsync":       "rsyncKeys",
+}
+
+func repoKeyAccessId(repoId, keyId string) string {
+	return repoId + "/" + keyId
+}
+
+// parseRepoKeyAccessId splits an ID of the form "repo_id/key_id".
+func parseRepoKeyAccessId(id string) (string, string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("expected ID of the form repo_id/key_id, got %q", id)
+	}
+	return parts[0], parts[1], nil
+}
+
+// keyLists returns the keys granted each access level on the repo.
+func (p BorgRepoPayload) keyLists() map[string][]string {
+	return map[string][]string{
+		"append_only": p.AppendOnlyKeys,
+		"full":        p.FullAccessKeys,
+		"rsync":       p.RsyncKeys,
+	}
+}
+
+// keyAccess returns the access level granted to the key on the repo, or ""
+// if the key has no access.
+func (p BorgRepoPayload) keyAccess(keyId string) string {
+	for access, keys := range p.keyLists() {
+		for _, key := range keys {
+			if key == keyId {
+				return access
+			}
+		}
+	}
+	return ""
+}
+
+// setKeyAccessArguments adds the key lists which need to change to grant the
+// key the given access level to args, removing it from any other lists. If
+// access is "", the key is removed from every list.
+func (p BorgRepoPayload) setKeyAccessArguments(
+	args gql.Arguments,
+	keyId string,
+	access string,
+) {
+	for level, keys := range p.keyLists() {
+		updated := make([]string, 0, len(keys)+1)
+		changed := false
+		for _, key := range keys {
+			if key == keyId && level != access {
+				changed = true
+				continue
+			}
+			updated = append(updated, key)
+		}
+		if level == access && p.keyAccess(keyId) != access {
+			updated = append(updated, keyId)
+			changed = true
+		}
+
+		if changed {
+			args[keyAccessLevels[level]] = gql.Optional(updated)
+		}
+	}
+}
+
+// replaceKeyArguments adds the key lists which grant oldId access to args,
+// with newId in its place.
+func (p BorgRepoPayload) replaceKeyArguments(
+	args gql.Arguments,
+	oldId string,
+	newId string,
+) {
+	for level, keys := range p.keyLists() {
+		updated := make([]string, 0, len(keys))
+		changed := false
+		for _, key := range keys {
+			if key == oldId {
+				key = newId
+				changed = true
+			}
+			if !containsString(updated, key) {
+				updated = append(updated, key)
+			}
+		}
+
+		if changed {
+			args[keyAccessLevels[level]] = gql.Optional(updated)
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// repoLocks serialises read-modify-write updates of each repo's key lists,
+// so that concurrent grants on the same repo don't overwrite each other.
+var repoLocks = struct {
+	sync.Mutex
+	locks map[string]*sync.Mutex
+}{locks: make(map[string]*sync.Mutex)}
+
+// lockRepo locks the repo with the given ID and returns a function to unlock
+// it again.
+func lockRepo(id string) func() {
+	repoLocks.Lock()
+	lock, ok := repoLocks.locks[id]
+	if !ok {
+		lock = &sync.Mutex{}
+		repoLocks.locks[id] = lock
+	}
+	repoLocks.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// findRepo returns the repo with the given ID, or nil if it doesn't exist.
+func findRepo(ctx context.Context, client *gql.Client, id string) (*BorgRepoPayload, error) {
+	var payload BorgReposPayload
+	if err := client.Query(ctx, "repoList", &payload, gql.Arguments{}); err != nil {
+		return nil, err
+	}
+
+	for _, item := range payload {
+		if item.Id == id {
+			return &item, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,309 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gjabell/terraform-provider-borgbase/gql"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &RepoKeyAccessResource{}
+var _ resource.ResourceWithImportState = &RepoKeyAccessResource{}
+
+func NewRepoKeyAccessResource() resource.Resource {
+	return &RepoKeyAccessResource{}
+}
+
+type RepoKeyAccessResource struct {
+	client *gql.Client
+}
+
+func (r *RepoKeyAccessResource) Metadata(
+	ctx context.Context,
+	req resource.MetadataRequest,
+	resp *resource.MetadataResponse,
+) {
+	resp.TypeName = req.ProviderTypeName + "_repo_key_access"
+}
+
+func (r *RepoKeyAccessResource) Schema(
+	ctx context.Context,
+	req resource.SchemaRequest,
+	resp *resource.SchemaResponse,
+) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Access of a single SSH key to a repository. " +
+			"Don't combine with the key lists of `borgbase_borg_repo` for the same repository.",
+		Attributes: map[string]schema.Attribute{
+			"access": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Access level granted to the key (full, append_only, or rsync).",
+				Validators: []validator.String{
+					stringvalidator.OneOf("full", "append_only", "rsync"),
+				},
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Identifier of the grant in the form `repo_id/key_id`.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"key_id": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Internal BorgBase identifier of the SSH key.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"repo_id": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Internal BorgBase identifier of the repository.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeoutsBlock(),
+		},
+	}
+}
+
+func (r *RepoKeyAccessResource) Configure(
+	ctx context.Context,
+	req resource.ConfigureRequest,
+	resp *resource.ConfigureResponse,
+) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*BorgBaseResourceData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider.BorgBaseResourceData, got: %T. "+
+				"Please report this issue to the provider developers.",
+				req.ProviderData),
+		)
+		return
+	}
+	r.client = data.Client
+}
+
+// setAccess grants the key the given access level on the repo, or revokes
+// its access if access is "".
+func (r *RepoKeyAccessResource) setAccess(
+	ctx context.Context,
+	repoId, keyId, access string,
+) error {
+	unlock := lockRepo(repoId)
+	defer unlock()
+
+	repo, err := findRepo(ctx, r.client, repoId)
+	if err != nil {
+		return err
+	}
+	if repo == nil {
+		// A deleted repo grants no access, so there's nothing to revoke.
+		if access == "" {
+			return nil
+		}
+		return fmt.Errorf("unknown borg repo %q", repoId)
+	}
+
+	args := gql.Arguments{"id": gql.Required(repoId)}
+	repo.setKeyAccessArguments(args, keyId, access)
+	if len(args) == 1 {
+		return nil
+	}
+
+	return r.client.Mutation(ctx, "repoEdit", &BorgRepoEditPayload{}, args)
+}
+
+func (r *RepoKeyAccessResource) Create(
+	ctx context.Context,
+	req resource.CreateRequest,
+	resp *resource.CreateResponse,
+) {
+	var data RepoKeyAccessModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diagnostics := withTimeout(ctx, data.Timeouts, "create")
+	resp.Diagnostics.Append(diagnostics...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	defer cancel()
+
+	err := r.setAccess(
+		ctx,
+		data.RepoId.ValueString(),
+		data.KeyId.ValueString(),
+		data.Access.ValueString(),
+	)
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Failed to grant repo access", err)
+		return
+	}
+	data.Id = types.StringValue(
+		repoKeyAccessId(data.RepoId.ValueString(), data.KeyId.ValueString()),
+	)
+
+	tflog.Trace(ctx, "granted repo access", map[string]interface{}{
+		"repo_id": data.RepoId,
+		"key_id":  data.KeyId,
+		"access":  data.Access,
+	})
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *RepoKeyAccessResource) Read(
+	ctx context.Context,
+	req resource.ReadRequest,
+	resp *resource.ReadResponse,
+) {
+	var data RepoKeyAccessModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diagnostics := withTimeout(ctx, data.Timeouts, "read")
+	resp.Diagnostics.Append(diagnostics...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	defer cancel()
+
+	repo, err := findRepo(ctx, r.client, data.RepoId.ValueString())
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Failed to read borg repo", err)
+		return
+	}
+
+	// The grant is gone if either the repo was deleted or the key's access
+	// was revoked outside of Terraform.
+	access := ""
+	if repo != nil {
+		access = repo.keyAccess(data.KeyId.ValueString())
+	}
+	if access == "" {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	data.Access = types.StringValue(access)
+	data.Id = types.StringValue(
+		repoKeyAccessId(data.RepoId.ValueString(), data.KeyId.ValueString()),
+	)
+
+	tflog.Trace(ctx, "read repo access", map[string]interface{}{
+		"repo_id": data.RepoId,
+		"key_id":  data.KeyId,
+		"access":  data.Access,
+	})
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *RepoKeyAccessResource) Update(
+	ctx context.Context,
+	req resource.UpdateRequest,
+	resp *resource.UpdateResponse,
+) {
+	var data RepoKeyAccessModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diagnostics := withTimeout(ctx, data.Timeouts, "update")
+	resp.Diagnostics.Append(diagnostics...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	defer cancel()
+
+	err := r.setAccess(
+		ctx,
+		data.RepoId.ValueString(),
+		data.KeyId.ValueString(),
+		data.Access.ValueString(),
+	)
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Failed to update repo access", err)
+		return
+	}
+
+	tflog.Trace(ctx, "updated repo access", map[string]interface{}{
+		"repo_id": data.RepoId,
+		"key_id":  data.KeyId,
+		"access":  data.Access,
+	})
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *RepoKeyAccessResource) Delete(
+	ctx context.Context,
+	req resource.DeleteRequest,
+	resp *resource.DeleteResponse,
+) {
+	var data RepoKeyAccessModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel, diagnostics := withTimeout(ctx, data.Timeouts, "delete")
+	resp.Diagnostics.Append(diagnostics...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	defer cancel()
+
+	err := r.setAccess(ctx, data.RepoId.ValueString(), data.KeyId.ValueString(), "")
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Failed to revoke repo access", err)
+	}
+
+	tflog.Trace(ctx, "revoked repo access", map[string]interface{}{
+		"repo_id": data.RepoId,
+		"key_id":  data.KeyId,
+	})
+}
+
+func (r *RepoKeyAccessResource) ImportState(
+	ctx context.Context,
+	req resource.ImportStateRequest,
+	resp *resource.ImportStateResponse,
+) {
+	repoId, keyId, err := parseRepoKeyAccessId(req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import ID", err.Error())
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("repo_id"), repoId)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("key_id"), keyId)...)
+}
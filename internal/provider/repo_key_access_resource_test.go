@@ -0,0 +1,270 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/gjabell/terraform-provider-borgbase/gql"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccRepoKeyAccessResource(t *testing.T) {
+	name := "terraform_test"
+
+	id := "borgbase_repo_key_access.test"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccRepoKeyAccessResourceConfig(name, "full"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(id, "access", "full"),
+					resource.TestCheckResourceAttrPair(
+						id, "repo_id", "borgbase_borg_repo.test", "id",
+					),
+					resource.TestCheckResourceAttrPair(
+						id, "key_id", "borgbase_ssh_key.test", "id",
+					),
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:      id,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			// Update and Read testing
+			{
+				Config: testAccRepoKeyAccessResourceConfig(name, "rsync"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(id, "access", "rsync"),
+				),
+			},
+			// Delete testing automatically occurs in TestCase
+		},
+	})
+}
+
+func TestParseRepoKeyAccessId(t *testing.T) {
+	repoId, keyId, err := parseRepoKeyAccessId("abc/123")
+	if err != nil || repoId != "abc" || keyId != "123" {
+		t.Errorf("expected abc, 123, got %q, %q, %v", repoId, keyId, err)
+	}
+
+	for _, id := range []string{"", "abc", "abc/", "/123", "abc/123/4"} {
+		if _, _, err := parseRepoKeyAccessId(id); err == nil {
+			t.Errorf("%q: expected error", id)
+		}
+	}
+}
+
+func TestBorgRepoPayloadSetKeyAccessArguments(t *testing.T) {
+	repo := BorgRepoPayload{
+		AppendOnlyKeys: []string{"2"},
+		FullAccessKeys: []string{"1", "3"},
+		RsyncKeys:      []string{},
+	}
+
+	for name, tc := range map[string]struct {
+		keyId, access string
+		expected      map[string]interface{}
+	}{
+		"new key": {
+			keyId:    "4",
+			access:   "rsync",
+			expected: map[string]interface{}{"rsyncKeys": []string{"4"}},
+		},
+		"unchanged": {
+			keyId:    "1",
+			access:   "full",
+			expected: map[string]interface{}{},
+		},
+		"moved": {
+			keyId:  "3",
+			access: "append_only",
+			expected: map[string]interface{}{
+				"appendOnlyKeys": []string{"2", "3"},
+				"fullAccessKeys": []string{"1"},
+			},
+		},
+		"revoked": {
+			keyId:    "2",
+			access:   "",
+			expected: map[string]interface{}{"appendOnlyKeys": []string{}},
+		},
+		"revoked without access": {
+			keyId:    "4",
+			access:   "",
+			expected: map[string]interface{}{},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			args := gql.Arguments{}
+			repo.setKeyAccessArguments(args, tc.keyId, tc.access)
+
+			actual := make(map[string]interface{}, len(args))
+			for name, arg := range args {
+				actual[name] = arg.Value()
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestBorgRepoPayloadReplaceKeyArguments(t *testing.T) {
+	repo := BorgRepoPayload{
+		AppendOnlyKeys: []string{"2"},
+		FullAccessKeys: []string{"1", "3"},
+		RsyncKeys:      []string{"3"},
+	}
+
+	for name, tc := range map[string]struct {
+		oldId, newId string
+		expected     map[string]interface{}
+	}{
+		"single list": {
+			oldId:    "2",
+			newId:    "4",
+			expected: map[string]interface{}{"appendOnlyKeys": []string{"4"}},
+		},
+		"several lists": {
+			oldId: "3",
+			newId: "4",
+			expected: map[string]interface{}{
+				"fullAccessKeys": []string{"1", "4"},
+				"rsyncKeys":      []string{"4"},
+			},
+		},
+		"already granted": {
+			oldId:    "1",
+			newId:    "3",
+			expected: map[string]interface{}{"fullAccessKeys": []string{"3"}},
+		},
+		"without access": {
+			oldId:    "5",
+			newId:    "4",
+			expected: map[string]interface{}{},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			args := gql.Arguments{}
+			repo.replaceKeyArguments(args, tc.oldId, tc.newId)
+
+			actual := make(map[string]interface{}, len(args))
+			for name, arg := range args {
+				actual[name] = arg.Value()
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRepoKeyAccessResourceSetAccess_concurrent(t *testing.T) {
+	repo := BorgRepoPayload{Id: "abc", FullAccessKeys: []string{}}
+	client, _ := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		switch req.Operation {
+		case "repoList":
+			return BorgReposPayload{repo}, nil
+		case "repoEdit":
+			repo.FullAccessKeys = nil
+			for _, key := range req.Variables["fullAccessKeys"].([]interface{}) {
+				repo.FullAccessKeys = append(repo.FullAccessKeys, key.(string))
+			}
+			return BorgRepoEditPayload{RepoEdited: repo}, nil
+		default:
+			return nil, fmt.Errorf("unexpected operation %s", req.Operation)
+		}
+	})
+	r := &RepoKeyAccessResource{client: client}
+
+	// Each grant reads the current key list before writing it back, so
+	// without locking some of the keys would be lost.
+	errs := make(chan error)
+	for i := 0; i < 10; i++ {
+		go func(keyId string) {
+			errs <- r.setAccess(context.Background(), "abc", keyId, "full")
+		}(fmt.Sprint(i))
+	}
+	for i := 0; i < 10; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(repo.FullAccessKeys) != 10 {
+		t.Errorf("expected 10 keys, got %v", repo.FullAccessKeys)
+	}
+}
+
+func TestRepoKeyAccessResourceDelete_deletedRepo(t *testing.T) {
+	ctx := context.Background()
+
+	client, api := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		if req.Operation != "repoList" {
+			return nil, fmt.Errorf("unexpected operation %s", req.Operation)
+		}
+		return BorgReposPayload{}, nil
+	})
+
+	r := &RepoKeyAccessResource{client: client}
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+
+	state := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	diagnostics := state.Set(ctx, &RepoKeyAccessModel{
+		Access:   types.StringValue("full"),
+		Id:       types.StringValue("abc/1"),
+		KeyId:    types.StringValue("1"),
+		RepoId:   types.StringValue("abc"),
+		Timeouts: types.ObjectNull(timeoutsAttributes),
+	})
+	if diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+
+	// A grant on a repo deleted outside of Terraform is already revoked.
+	var resp fwresource.DeleteResponse
+	r.Delete(ctx, fwresource.DeleteRequest{State: state}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatal(resp.Diagnostics)
+	}
+	if requests := api.Requests(); len(requests) != 1 {
+		t.Errorf("expected only the repo to be read, got %d operations", len(requests))
+	}
+}
+
+func testAccRepoKeyAccessResourceConfig(name, access string) string {
+	return fmt.Sprintf(`
+resource "borgbase_ssh_key" "test" {
+	name       = %[1]q
+	public_key = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBAt/X37WDQ3cNPEVHQBsW3lH7XPeea5rUoeXuhoTkzR terraform@localhost"
+}
+
+resource "borgbase_borg_repo" "test" {
+	deletion_protection = false
+	name                = %[1]q
+	region              = "eu"
+}
+
+resource "borgbase_repo_key_access" "test" {
+	repo_id = borgbase_borg_repo.test.id
+	key_id  = borgbase_ssh_key.test.id
+	access  = %[2]q
+}`, name, access)
+}
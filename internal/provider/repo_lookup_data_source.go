@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gjabell/terraform-provider-borgbase/gql"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// repoLookupDataSource is embedded by data sources which describe how to
+// access a borg repo configured by its repo_id or repo_name attribute.
+type repoLookupDataSource struct {
+	client *gql.Client
+}
+
+func (d *repoLookupDataSource) Configure(
+	ctx context.Context,
+	req datasource.ConfigureRequest,
+	resp *datasource.ConfigureResponse,
+) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*gql.Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf(
+				"Expected *gql.Client, got: %T. "+
+					"Please report this issue to the provider developers.",
+				req.ProviderData),
+		)
+		return
+	}
+	d.client = client
+}
+
+// withRepoLookupAttributes adds the repo_id and repo_name attributes to the
+// given attributes.
+func withRepoLookupAttributes(attributes map[string]schema.Attribute) map[string]schema.Attribute {
+	attributes["repo_id"] = schema.StringAttribute{
+		Computed:            true,
+		Optional:            true,
+		MarkdownDescription: "Internal BorgBase identifier of the repository. Exactly one of `repo_id` and `repo_name` must be set.",
+		Validators: []validator.String{
+			stringvalidator.ExactlyOneOf(path.MatchRoot("repo_name")),
+		},
+	}
+	attributes["repo_name"] = schema.StringAttribute{
+		Computed:            true,
+		Optional:            true,
+		MarkdownDescription: "Name of the repository. Exactly one of `repo_id` and `repo_name` must be set.",
+	}
+	return attributes
+}
+
+// readRepo returns the repo with the given ID or name, adding an error and
+// returning nil if it doesn't exist.
+func (d *repoLookupDataSource) readRepo(
+	ctx context.Context,
+	id types.String,
+	name types.String,
+	diagnostics *diag.Diagnostics,
+) *BorgRepoPayload {
+	repo, err := findRepoByIdOrName(ctx, d.client, id.ValueString(), name.ValueString())
+	if err != nil {
+		diagnostics.AddError("Failed to read borg repo", err.Error())
+		return nil
+	}
+	if repo == nil {
+		diagnostics.AddError("Unknown borg repo", repoReference(id, name))
+		return nil
+	}
+	return repo
+}
+
+// findRepoByIdOrName returns the repo with the given ID, or the given name if
+// the ID is empty, or nil if it doesn't exist.
+func findRepoByIdOrName(
+	ctx context.Context,
+	client *gql.Client,
+	id string,
+	name string,
+) (*BorgRepoPayload, error) {
+	if id != "" {
+		return findRepo(ctx, client, id)
+	}
+
+	var payload BorgReposPayload
+	args := gql.Arguments{"name": gql.Optional(name)}
+	if err := client.Query(ctx, "repoList", &payload, args); err != nil {
+		return nil, err
+	}
+
+	for _, item := range payload {
+		if item.Name == name {
+			return &item, nil
+		}
+	}
+	return nil, nil
+}
+
+// repoReference describes a repo configured by ID or name in errors.
+func repoReference(id, name types.String) string {
+	if !id.IsNull() {
+		return fmt.Sprintf("ID %s", id)
+	}
+	return fmt.Sprintf("name %s", name)
+}
@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultSshPort is used for repo paths which don't specify a port.
+const defaultSshPort = 22
+
+// repoPath is a repo path of the form ssh://user@host[:port]/path split into
+// its parts.
+type repoPath struct {
+	User string
+	Host string
+	Port int64
+	// Path is the path of the repo on the server, which is relative to the
+	// user's home directory if it starts with "./" or "~".
+	Path string
+}
+
+// parseRepoPath splits a BorgBase repo path such as
+// ssh://abc123@abc123.repo.borgbase.com/./repo into its parts.
+func parseRepoPath(value string) (repoPath, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return repoPath{}, fmt.Errorf("invalid repo path %q: %w", value, err)
+	}
+	if u.Scheme != "ssh" {
+		return repoPath{}, fmt.Errorf("invalid repo path %q: expected an ssh:// URL", value)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return repoPath{}, fmt.Errorf("invalid repo path %q: missing user", value)
+	}
+	if u.Hostname() == "" {
+		return repoPath{}, fmt.Errorf("invalid repo path %q: missing host", value)
+	}
+
+	port := int64(defaultSshPort)
+	if u.Port() != "" {
+		port, err = strconv.ParseInt(u.Port(), 10, 32)
+		if err != nil || port < 1 || port > 65535 {
+			return repoPath{}, fmt.Errorf("invalid repo path %q: invalid port %q", value, u.Port())
+		}
+	}
+
+	// Borg treats "/./repo" and "/~/repo" as paths relative to the home
+	// directory.
+	path := u.Path
+	if strings.HasPrefix(path, "/./") || strings.HasPrefix(path, "/~") {
+		path = path[1:]
+	}
+	if path == "" || path == "/" {
+		return repoPath{}, fmt.Errorf("invalid repo path %q: missing path", value)
+	}
+
+	return repoPath{
+		User: u.User.Username(),
+		Host: u.Hostname(),
+		Port: port,
+		Path: path,
+	}, nil
+}
+
+// address returns the host, followed by the port if it isn't the default.
+func (p repoPath) address() string {
+	if p.Port != defaultSshPort {
+		return net.JoinHostPort(p.Host, strconv.FormatInt(p.Port, 10))
+	}
+	if strings.Contains(p.Host, ":") {
+		return "[" + p.Host + "]"
+	}
+	return p.Host
+}
+
+// urlPath returns the path in the form used by ssh:// and sftp:// URLs, where
+// paths relative to the home directory start with "/./" or "/~".
+func (p repoPath) urlPath() string {
+	if strings.HasPrefix(p.Path, "/") {
+		return p.Path
+	}
+	return "/" + p.Path
+}
+
+// borgUrl returns the URL of the repo for borg, e.g.
+// ssh://abc123@abc123.repo.borgbase.com/./repo.
+func (p repoPath) borgUrl() string {
+	return "ssh://" + p.User + "@" + p.address() + p.urlPath()
+}
+
+// rsyncUrl returns the location of the repo for rsync over SSH, e.g.
+// abc123@abc123.repo.borgbase.com:repo. The port can't be part of it and has
+// to be passed to ssh separately.
+func (p repoPath) rsyncUrl() string {
+	host := p.Host
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return p.User + "@" + host + ":" + strings.TrimPrefix(p.Path, "./")
+}
+
+// sftpUrl returns the URL of the repo for SFTP clients, e.g.
+// sftp://abc123@abc123.repo.borgbase.com/./repo.
+func (p repoPath) sftpUrl() string {
+	return "sftp://" + p.User + "@" + p.address() + p.urlPath()
+}
@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"testing"
+)
+
+func TestParseRepoPath(t *testing.T) {
+	for value, expected := range map[string]repoPath{
+		"ssh://abc123@abc123.repo.borgbase.com/./repo": {
+			User: "abc123",
+			Host: "abc123.repo.borgbase.com",
+			Port: 22,
+			Path: "./repo",
+		},
+		"ssh://abc123@abc123.repo.borgbase.com:2222/./repo": {
+			User: "abc123",
+			Host: "abc123.repo.borgbase.com",
+			Port: 2222,
+			Path: "./repo",
+		},
+		"ssh://borg@backup.example.com/~/repos/web": {
+			User: "borg",
+			Host: "backup.example.com",
+			Port: 22,
+			Path: "~/repos/web",
+		},
+		"ssh://borg@[2001:db8::1]:23/srv/borg": {
+			User: "borg",
+			Host: "2001:db8::1",
+			Port: 23,
+			Path: "/srv/borg",
+		},
+	} {
+		actual, err := parseRepoPath(value)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", value, err)
+			continue
+		}
+		if actual != expected {
+			t.Errorf("%s: expected %+v, got %+v", value, expected, actual)
+		}
+	}
+
+	for _, value := range []string{
+		"",
+		"abc123@abc123.repo.borgbase.com:repo",
+		"https://abc123.repo.borgbase.com/./repo",
+		"ssh://abc123.repo.borgbase.com/./repo",
+		"ssh://abc123@/./repo",
+		"ssh://abc123@abc123.repo.borgbase.com:0/./repo",
+		"ssh://abc123@abc123.repo.borgbase.com:ssh/./repo",
+		"ssh://abc123@abc123.repo.borgbase.com",
+		"ssh://abc123@abc123.repo.borgbase.com/",
+	} {
+		if actual, err := parseRepoPath(value); err == nil {
+			t.Errorf("%q: expected error, got %+v", value, actual)
+		}
+	}
+}
+
+func TestRepoPathUrls(t *testing.T) {
+	for value, expected := range map[string][3]string{
+		"ssh://abc123@abc123.repo.borgbase.com/./repo": {
+			"ssh://abc123@abc123.repo.borgbase.com/./repo",
+			"abc123@abc123.repo.borgbase.com:repo",
+			"sftp://abc123@abc123.repo.borgbase.com/./repo",
+		},
+		"ssh://abc123@abc123.repo.borgbase.com:22/./repo": {
+			"ssh://abc123@abc123.repo.borgbase.com/./repo",
+			"abc123@abc123.repo.borgbase.com:repo",
+			"sftp://abc123@abc123.repo.borgbase.com/./repo",
+		},
+		"ssh://borg@backup.example.com:2222/~/repos/web": {
+			"ssh://borg@backup.example.com:2222/~/repos/web",
+			"borg@backup.example.com:~/repos/web",
+			"sftp://borg@backup.example.com:2222/~/repos/web",
+		},
+		"ssh://borg@[2001:db8::1]/srv/borg": {
+			"ssh://borg@[2001:db8::1]/srv/borg",
+			"borg@[2001:db8::1]:/srv/borg",
+			"sftp://borg@[2001:db8::1]/srv/borg",
+		},
+	} {
+		parsed, err := parseRepoPath(value)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", value, err)
+			continue
+		}
+		actual := [3]string{parsed.borgUrl(), parsed.rsyncUrl(), parsed.sftpUrl()}
+		if actual != expected {
+			t.Errorf("%s: expected %q, got %q", value, expected, actual)
+		}
+	}
+}
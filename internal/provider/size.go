@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// bytesPerMegabyte is the size of the megabytes used by the BorgBase API for
+// quotas and usage.
+const bytesPerMegabyte = 1 << 20
+
+var sizeUnits = map[string]float64{
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"pb":  1e15,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+}
+
+var sizeRegexp = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)\s*([A-Za-z]+)\s*$`)
+
+// parseSize parses a human-readable size such as "500GB", "1TiB" or
+// "250 GiB" and returns it in BorgBase megabytes, rounded to the nearest
+// megabyte. Decimal (KB, MB, ...) and binary (KiB, MiB, ...) units are
+// supported.
+func parseSize(s string) (int64, error) {
+	match := sizeRegexp.FindStringSubmatch(s)
+	if match == nil {
+		return 0, fmt.Errorf("expected a size such as \"500GB\" or \"1TiB\", got %q", s)
+	}
+
+	unit, ok := sizeUnits[strings.ToLower(match[2])]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit %q in %q", match[2], s)
+	}
+
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+	return int64(math.Round(value * unit / bytesPerMegabyte)), nil
+}
+
+// formatSize formats a size in BorgBase megabytes using the largest binary
+// unit which represents it exactly.
+func formatSize(megabytes int64) string {
+	switch {
+	case megabytes != 0 && megabytes%(1<<20) == 0:
+		return fmt.Sprintf("%dTiB", megabytes>>20)
+	case megabytes != 0 && megabytes%(1<<10) == 0:
+		return fmt.Sprintf("%dGiB", megabytes>>10)
+	default:
+		return fmt.Sprintf("%dMiB", megabytes)
+	}
+}
+
+// sizeValidator checks that a string is a valid size for parseSize.
+type sizeValidator struct{}
+
+func (v sizeValidator) Description(ctx context.Context) string {
+	return "value must be a size such as \"500GB\" or \"1TiB\""
+}
+
+func (v sizeValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v sizeValidator) ValidateString(
+	ctx context.Context,
+	req validator.StringRequest,
+	resp *validator.StringResponse,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := parseSize(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid size", err.Error())
+	}
+}
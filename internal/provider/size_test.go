@@ -0,0 +1,50 @@
+package provider
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	for s, expected := range map[string]int64{
+		"0GB":      0,
+		"1MiB":     1,
+		"1048576B": 1,
+		"1.5GiB":   1536,
+		"250 GiB":  256000,
+		"1TiB":     1048576,
+		"1tib":     1048576,
+		"500GB":    476837,
+		"1TB":      953674,
+		" 10 MB ":  10,
+	} {
+		actual, err := parseSize(s)
+		if err != nil {
+			t.Errorf("%q: %s", s, err)
+			continue
+		}
+		if actual != expected {
+			t.Errorf("%q: expected %d, got %d", s, expected, actual)
+		}
+	}
+
+	for _, s := range []string{"", "GB", "10", "10 XB", "-1GB", "1,5GB"} {
+		if _, err := parseSize(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for megabytes, expected := range map[int64]string{
+		0:       "0MiB",
+		10:      "10MiB",
+		1536:    "1536MiB",
+		256000:  "250GiB",
+		1048576: "1TiB",
+	} {
+		if actual := formatSize(megabytes); actual != expected {
+			t.Errorf("%d: expected %q, got %q", megabytes, expected, actual)
+		}
+		if parsed, err := parseSize(expected); err != nil || parsed != megabytes {
+			t.Errorf("%q: expected %d, got %d (%v)", expected, megabytes, parsed, err)
+		}
+	}
+}
@@ -104,16 +104,18 @@ func (d *SshKeyDataSource) Read(
 	req datasource.ReadRequest,
 	resp *datasource.ReadResponse,
 ) {
-	var data SshKeyModel
+	var config SshKeyDataSourceModel
 
-	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	data := SshKeyModel{Name: config.Name}
+
 	var payload SshKeysPayload
 	args := gql.Arguments{"name": gql.Optional(data.Name.ValueString())}
-	if err := d.client.Query("sshList", &payload, args); err != nil {
+	if err := d.client.Query(ctx, "sshList", &payload, args); err != nil {
 		resp.Diagnostics.AddError("Failed to read SSH key", err.Error())
 		return
 	}
@@ -137,5 +139,5 @@ func (d *SshKeyDataSource) Read(
 		"public_key": data.PublicKey,
 	})
 
-	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, data.dataSourceModel())...)
 }
@@ -1,11 +1,14 @@
 package provider
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 )
 
@@ -56,6 +59,19 @@ func TestAccSshKeyDataSource(t *testing.T) {
 	})
 }
 
+func TestSshKeyModelDataSourceModel(t *testing.T) {
+	testDataSourceModelComplete(
+		t,
+		NewSshKeyResource(),
+		NewSshKeyDataSource(),
+		func(state tfsdk.State) (interface{}, diag.Diagnostics) {
+			var data SshKeyModel
+			diagnostics := state.Get(context.Background(), &data)
+			return data.dataSourceModel(), diagnostics
+		},
+	)
+}
+
 func testAccSshKeyDataSourceConfig(name string) string {
 	return fmt.Sprintf(`
 resource "borgbase_ssh_key" "test" {
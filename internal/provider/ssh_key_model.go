@@ -1,6 +1,9 @@
 package provider
 
 import (
+	"context"
+
+	"github.com/gjabell/terraform-provider-borgbase/gql"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
@@ -13,9 +16,38 @@ type SshKeyModel struct {
 	LastUsedAt types.String `tfsdk:"last_used_at"`
 	Name       types.String `tfsdk:"name"`
 	PublicKey  types.String `tfsdk:"public_key"`
+	Timeouts   types.Object `tfsdk:"timeouts"`
 	Type       types.String `tfsdk:"type"`
 }
 
+// SshKeyDataSourceModel is SshKeyModel without the attributes which only the
+// resource has.
+type SshKeyDataSourceModel struct {
+	AddedAt    types.String `tfsdk:"added_at"`
+	Bits       types.Int64  `tfsdk:"bits"`
+	HashMd5    types.String `tfsdk:"hash_md5"`
+	HashSha256 types.String `tfsdk:"hash_sha256"`
+	Id         types.String `tfsdk:"id"`
+	LastUsedAt types.String `tfsdk:"last_used_at"`
+	Name       types.String `tfsdk:"name"`
+	PublicKey  types.String `tfsdk:"public_key"`
+	Type       types.String `tfsdk:"type"`
+}
+
+func (m *SshKeyModel) dataSourceModel() SshKeyDataSourceModel {
+	return SshKeyDataSourceModel{
+		AddedAt:    m.AddedAt,
+		Bits:       m.Bits,
+		HashMd5:    m.HashMd5,
+		HashSha256: m.HashSha256,
+		Id:         m.Id,
+		LastUsedAt: m.LastUsedAt,
+		Name:       m.Name,
+		PublicKey:  m.PublicKey,
+		Type:       m.Type,
+	}
+}
+
 func (m *SshKeyModel) update(key SshKeyPayload) {
 	m.AddedAt = types.StringValue(key.AddedAt)
 	m.Bits = types.Int64Value(int64(key.Bits))
@@ -49,6 +81,39 @@ type SshKeyPayload struct {
 
 type SshKeysPayload []SshKeyPayload
 
+// findSshKey returns the SSH key with the given name, or nil if it doesn't
+// exist.
+func findSshKey(ctx context.Context, client *gql.Client, name string) (*SshKeyPayload, error) {
+	var payload SshKeysPayload
+	args := gql.Arguments{"name": gql.Optional(name)}
+	if err := client.Query(ctx, "sshList", &payload, args); err != nil {
+		return nil, err
+	}
+
+	for _, item := range payload {
+		if item.Name == name {
+			return &item, nil
+		}
+	}
+	return nil, nil
+}
+
+// findSshKeyById returns the SSH key with the given ID, or nil if it doesn't
+// exist.
+func findSshKeyById(ctx context.Context, client *gql.Client, id string) (*SshKeyPayload, error) {
+	var payload SshKeysPayload
+	if err := client.Query(ctx, "sshList", &payload, gql.Arguments{}); err != nil {
+		return nil, err
+	}
+
+	for _, item := range payload {
+		if item.Id == id {
+			return &item, nil
+		}
+	}
+	return nil, nil
+}
+
 type SshAddPayload struct {
 	KeyAdded SshKeyPayload `json:"keyAdded"`
 }
@@ -106,6 +106,9 @@ func (r *SshKeyResource) Schema(
 				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeoutsBlock(),
+		},
 	}
 }
 
@@ -118,17 +121,17 @@ func (r *SshKeyResource) Configure(
 		return
 	}
 
-	client, ok := req.ProviderData.(*gql.Client)
+	data, ok := req.ProviderData.(*BorgBaseResourceData)
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *gql.Client, got: %T. "+
+			fmt.Sprintf("Expected *provider.BorgBaseResourceData, got: %T. "+
 				"Please report this issue to the provider developers.",
 				req.ProviderData),
 		)
 		return
 	}
-	r.client = client
+	r.client = data.Client
 }
 
 func (r *SshKeyResource) Create(
@@ -143,13 +146,20 @@ func (r *SshKeyResource) Create(
 		return
 	}
 
+	ctx, cancel, diagnostics := withTimeout(ctx, data.Timeouts, "create")
+	resp.Diagnostics.Append(diagnostics...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	defer cancel()
+
 	var payload SshAddPayload
 	args := gql.Arguments{
 		"name":    gql.Optional(data.Name.ValueString()),
 		"keyData": gql.Optional(data.PublicKey.ValueString()),
 	}
-	if err := r.client.Mutation("sshAdd", &payload, args); err != nil {
-		resp.Diagnostics.AddError("Failed to create SSH key", err.Error())
+	if err := r.client.Mutation(ctx, "sshAdd", &payload, args); err != nil {
+		addClientError(&resp.Diagnostics, "Failed to create SSH key", err)
 		return
 	}
 	data.update(payload.KeyAdded)
@@ -175,19 +185,17 @@ func (r *SshKeyResource) Read(
 		return
 	}
 
-	var payload SshKeysPayload
-	args := gql.Arguments{"name": gql.Optional(data.Name.ValueString())}
-	if err := r.client.Query("sshList", &payload, args); err != nil {
-		resp.Diagnostics.AddError("Failed to read SSH key", err.Error())
+	ctx, cancel, diagnostics := withTimeout(ctx, data.Timeouts, "read")
+	resp.Diagnostics.Append(diagnostics...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
+	defer cancel()
 
-	var key *SshKeyPayload
-	for _, item := range payload {
-		if item.Name == data.Name.ValueString() {
-			key = &item
-			break
-		}
+	key, err := findSshKey(ctx, r.client, data.Name.ValueString())
+	if err != nil {
+		addClientError(&resp.Diagnostics, "Failed to read SSH key", err)
+		return
 	}
 	if key == nil {
 		resp.Diagnostics.AddError("Unknown SSH key", data.Name.String())
@@ -209,7 +217,16 @@ func (r *SshKeyResource) Update(
 	req resource.UpdateRequest,
 	resp *resource.UpdateResponse,
 ) {
-	// Update is not supported for SSH keys.
+	var data SshKeyModel
+
+	// Changing any other attribute replaces the key, so only the timeouts
+	// can have changed.
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *SshKeyResource) Delete(
@@ -224,9 +241,16 @@ func (r *SshKeyResource) Delete(
 		return
 	}
 
+	ctx, cancel, diagnostics := withTimeout(ctx, data.Timeouts, "delete")
+	resp.Diagnostics.Append(diagnostics...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	defer cancel()
+
 	args := gql.Arguments{"id": gql.Required(data.Id.ValueString())}
-	if err := r.client.Mutation("sshDelete", &SshDeletePayload{}, args); err != nil {
-		resp.Diagnostics.AddError("Failed to delete SSH key", err.Error())
+	if err := r.client.Mutation(ctx, "sshDelete", &SshDeletePayload{}, args); err != nil {
+		addClientError(&resp.Diagnostics, "Failed to delete SSH key", err)
 	}
 
 	tflog.Trace(ctx, "deleted SSH key", map[string]interface{}{
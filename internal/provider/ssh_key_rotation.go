@@ -0,0 +1,168 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gjabell/terraform-provider-borgbase/gql"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// timeNow returns the current time, and is replaced in tests.
+var timeNow = time.Now
+
+type RotationModel struct {
+	RotateAfter types.String `tfsdk:"rotate_after"`
+	Trigger     types.String `tfsdk:"trigger"`
+}
+
+// rotationPeriodPattern matches periods in days or weeks, which
+// time.ParseDuration doesn't support.
+var rotationPeriodPattern = regexp.MustCompile(`^([0-9]+)([dw])$`)
+
+// parseRotationPeriod parses a period such as "90d", "2w" or "12h".
+func parseRotationPeriod(value string) (time.Duration, error) {
+	var period time.Duration
+	if match := rotationPeriodPattern.FindStringSubmatch(value); match != nil {
+		count, err := strconv.Atoi(match[1])
+		if err != nil {
+			return 0, err
+		}
+		period = time.Duration(count) * 24 * time.Hour
+		if match[2] == "w" {
+			period *= 7
+		}
+	} else {
+		var err error
+		if period, err = time.ParseDuration(value); err != nil {
+			return 0, err
+		}
+	}
+
+	if period <= 0 {
+		return 0, errors.New("period must be positive")
+	}
+	return period, nil
+}
+
+// rotationPeriodValidator checks that a string is a valid rotation period.
+type rotationPeriodValidator struct{}
+
+func (v rotationPeriodValidator) Description(ctx context.Context) string {
+	return "value must be a positive period such as \"90d\", \"2w\" or \"12h\""
+}
+
+func (v rotationPeriodValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v rotationPeriodValidator) ValidateString(
+	ctx context.Context,
+	req validator.StringRequest,
+	resp *validator.StringResponse,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := parseRotationPeriod(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid rotation period",
+			fmt.Sprintf("%q is not a valid period: %s",
+				req.ConfigValue.ValueString(), err),
+		)
+	}
+}
+
+// rotationReason returns why the key in state needs to be replaced by a new
+// keypair according to the planned rotation settings, or "" if it doesn't.
+// It only compares values from the state and the plan, so that planning gives
+// the same result when the plan is applied later.
+func rotationReason(state GeneratedSshKeyModel, plan RotationModel) string {
+	var prior RotationModel
+	if !state.Rotation.IsNull() && !state.Rotation.IsUnknown() {
+		attributes := state.Rotation.Attributes()
+		prior.Trigger, _ = attributes["trigger"].(types.String)
+	}
+	// Setting the first trigger or removing it only records the change.
+	if !prior.Trigger.IsNull() && !plan.Trigger.IsNull() &&
+		!prior.Trigger.Equal(plan.Trigger) {
+		return "rotation trigger changed"
+	}
+
+	if state.RotationDue.ValueBool() && !plan.RotateAfter.IsNull() && !plan.RotateAfter.IsUnknown() {
+		return fmt.Sprintf("key is older than rotate_after (%s)", plan.RotateAfter.ValueString())
+	}
+	return ""
+}
+
+// rotationDue returns whether the keypair is older than the rotate_after
+// period of its rotation settings at the given time.
+func rotationDue(m GeneratedSshKeyModel, now time.Time) bool {
+	if m.Rotation.IsNull() || m.Rotation.IsUnknown() {
+		return false
+	}
+	rotateAfter, ok := m.Rotation.Attributes()["rotate_after"].(types.String)
+	if !ok || rotateAfter.IsNull() || rotateAfter.IsUnknown() {
+		return false
+	}
+	period, err := parseRotationPeriod(rotateAfter.ValueString())
+	if err != nil {
+		return false
+	}
+
+	// Keys generated before generated_at was tracked were generated when
+	// they were added to BorgBase.
+	generatedAt, err := time.Parse(time.RFC3339, m.GeneratedAt.ValueString())
+	if m.GeneratedAt.IsNull() {
+		generatedAt, err = time.Parse(time.RFC3339, m.AddedAt.ValueString())
+	}
+	return err == nil && !now.Before(generatedAt.Add(period))
+}
+
+// replaceRepoKey swaps newId into the key lists of every repo which grants
+// oldId access.
+func replaceRepoKey(ctx context.Context, client *gql.Client, oldId, newId string) error {
+	var repos BorgReposPayload
+	if err := client.Query(ctx, "repoList", &repos, gql.Arguments{}); err != nil {
+		return err
+	}
+
+	for _, repo := range repos {
+		if repo.keyAccess(oldId) == "" {
+			continue
+		}
+		if err := replaceKeyInRepo(ctx, client, repo.Id, oldId, newId); err != nil {
+			return fmt.Errorf("failed to update keys of borg repo %q: %w", repo.Name, err)
+		}
+	}
+	return nil
+}
+
+// replaceKeyInRepo swaps newId into the key lists of the repo with the given
+// ID which grant oldId access.
+func replaceKeyInRepo(ctx context.Context, client *gql.Client, repoId, oldId, newId string) error {
+	unlock := lockRepo(repoId)
+	defer unlock()
+
+	// Re-read the repo now that it's locked, in case its keys were changed
+	// since it was listed.
+	repo, err := findRepo(ctx, client, repoId)
+	if err != nil || repo == nil {
+		return err
+	}
+
+	args := gql.Arguments{"id": gql.Required(repoId)}
+	repo.replaceKeyArguments(args, oldId, newId)
+	if len(args) == 1 {
+		return nil
+	}
+
+	return client.Mutation(ctx, "repoEdit", &BorgRepoEditPayload{}, args)
+}
@@ -0,0 +1,150 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestParseRotationPeriod(t *testing.T) {
+	for value, expected := range map[string]time.Duration{
+		"90d":   90 * 24 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
+		"12h":   12 * time.Hour,
+		"1h30m": 90 * time.Minute,
+	} {
+		if actual, err := parseRotationPeriod(value); err != nil || actual != expected {
+			t.Errorf("%s: expected %s, got %s, %v", value, expected, actual, err)
+		}
+	}
+
+	for _, value := range []string{"", "0d", "-1h", "d", "1y", "1.5d"} {
+		if _, err := parseRotationPeriod(value); err == nil {
+			t.Errorf("%s: expected error", value)
+		}
+	}
+}
+
+func testRotation(rotateAfter, trigger types.String) types.Object {
+	return types.ObjectValueMust(
+		map[string]attr.Type{
+			"rotate_after": types.StringType,
+			"trigger":      types.StringType,
+		},
+		map[string]attr.Value{
+			"rotate_after": rotateAfter,
+			"trigger":      trigger,
+		},
+	)
+}
+
+func TestRotationDue(t *testing.T) {
+	now := time.Date(2023, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		state    GeneratedSshKeyModel
+		expected bool
+	}{
+		"no rotation": {
+			state: GeneratedSshKeyModel{
+				GeneratedAt: types.StringValue("2020-01-01T00:00:00Z"),
+				Rotation:    types.ObjectNull(nil),
+			},
+		},
+		"trigger only": {
+			state: GeneratedSshKeyModel{
+				GeneratedAt: types.StringValue("2020-01-01T00:00:00Z"),
+				Rotation:    testRotation(types.StringNull(), types.StringValue("1")),
+			},
+		},
+		"not due": {
+			state: GeneratedSshKeyModel{
+				GeneratedAt: types.StringValue("2023-06-01T12:00:00Z"),
+				Rotation:    testRotation(types.StringValue("90d"), types.StringNull()),
+			},
+		},
+		"due": {
+			state: GeneratedSshKeyModel{
+				GeneratedAt: types.StringValue("2023-04-01T12:00:00Z"),
+				Rotation:    testRotation(types.StringValue("90d"), types.StringNull()),
+			},
+			expected: true,
+		},
+		"due since added": {
+			state: GeneratedSshKeyModel{
+				AddedAt:     types.StringValue("2023-04-01T12:00:00+00:00"),
+				GeneratedAt: types.StringNull(),
+				Rotation:    testRotation(types.StringValue("2w"), types.StringNull()),
+			},
+			expected: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if actual := rotationDue(tc.state, now); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRotationReason(t *testing.T) {
+	for name, tc := range map[string]struct {
+		state    GeneratedSshKeyModel
+		plan     RotationModel
+		expected string
+	}{
+		"no rotation": {
+			state: GeneratedSshKeyModel{RotationDue: types.BoolValue(true)},
+		},
+		"not due": {
+			state: GeneratedSshKeyModel{RotationDue: types.BoolValue(false)},
+			plan:  RotationModel{RotateAfter: types.StringValue("90d")},
+		},
+		"due": {
+			state:    GeneratedSshKeyModel{RotationDue: types.BoolValue(true)},
+			plan:     RotationModel{RotateAfter: types.StringValue("90d")},
+			expected: "key is older than rotate_after (90d)",
+		},
+		"not refreshed yet": {
+			state: GeneratedSshKeyModel{RotationDue: types.BoolNull()},
+			plan:  RotationModel{RotateAfter: types.StringValue("90d")},
+		},
+		"trigger changed": {
+			state: GeneratedSshKeyModel{
+				Rotation: testRotation(types.StringNull(), types.StringValue("1")),
+			},
+			plan:     RotationModel{Trigger: types.StringValue("2")},
+			expected: "rotation trigger changed",
+		},
+		"trigger unknown": {
+			state: GeneratedSshKeyModel{
+				Rotation: testRotation(types.StringNull(), types.StringValue("1")),
+			},
+			plan:     RotationModel{Trigger: types.StringUnknown()},
+			expected: "rotation trigger changed",
+		},
+		"trigger unchanged": {
+			state: GeneratedSshKeyModel{
+				Rotation: testRotation(types.StringNull(), types.StringValue("1")),
+			},
+			plan: RotationModel{Trigger: types.StringValue("1")},
+		},
+		"first trigger": {
+			state: GeneratedSshKeyModel{Rotation: types.ObjectNull(nil)},
+			plan:  RotationModel{Trigger: types.StringValue("1")},
+		},
+		"trigger removed": {
+			state: GeneratedSshKeyModel{
+				Rotation: testRotation(types.StringNull(), types.StringValue("1")),
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if actual := rotationReason(tc.state, tc.plan); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
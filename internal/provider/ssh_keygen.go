@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// generatedKeyRsaBits is the size of generated RSA keys, matching the
+// default of ssh-keygen.
+const generatedKeyRsaBits = 3072
+
+// generatedKeyTypes are the supported values of the key_type attribute of
+// generated SSH keys.
+var generatedKeyTypes = []string{"ecdsa", "ed25519", "rsa"}
+
+// generateSshKey generates an SSH keypair of the given type, returning the
+// private key as an unencrypted OpenSSH PEM block and the public key in
+// authorized_keys format, followed by the comment if it isn't empty.
+func generateSshKey(keyType, comment string) (string, string, error) {
+	var private crypto.Signer
+	var err error
+	switch keyType {
+	case "ecdsa":
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ed25519":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case "rsa":
+		private, err = rsa.GenerateKey(rand.Reader, generatedKeyRsaBits)
+	default:
+		return "", "", fmt.Errorf("unsupported key type %q", keyType)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate %s key: %w", keyType, err)
+	}
+
+	sshPublic, err := ssh.NewPublicKey(private.Public())
+	if err != nil {
+		return "", "", err
+	}
+	block, err := marshalOpenSSHPrivateKey(private, sshPublic, comment)
+	if err != nil {
+		return "", "", err
+	}
+
+	publicKey := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(sshPublic)), "\n")
+	if comment != "" {
+		publicKey += " " + comment
+	}
+	return string(pem.EncodeToMemory(block)), publicKey, nil
+}
+
+// marshalOpenSSHPrivateKey encodes an unencrypted private key in the
+// openssh-key-v1 format written by ssh-keygen, which golang.org/x/crypto/ssh
+// can parse but not yet write.
+func marshalOpenSSHPrivateKey(
+	private crypto.Signer,
+	public ssh.PublicKey,
+	comment string,
+) (*pem.Block, error) {
+	var keyFields []byte
+	switch key := private.(type) {
+	case *ecdsa.PrivateKey:
+		keyFields = ssh.Marshal(struct {
+			Curve string
+			Pub   []byte
+			D     *big.Int
+		}{
+			"nistp256",
+			elliptic.Marshal(key.Curve, key.X, key.Y),
+			key.D,
+		})
+	case ed25519.PrivateKey:
+		keyFields = ssh.Marshal(struct {
+			Pub  []byte
+			Priv []byte
+		}{
+			[]byte(key.Public().(ed25519.PublicKey)),
+			[]byte(key),
+		})
+	case *rsa.PrivateKey:
+		keyFields = ssh.Marshal(struct {
+			N    *big.Int
+			E    *big.Int
+			D    *big.Int
+			Iqmp *big.Int
+			P    *big.Int
+			Q    *big.Int
+		}{
+			key.N,
+			big.NewInt(int64(key.E)),
+			key.D,
+			key.Precomputed.Qinv,
+			key.Primes[0],
+			key.Primes[1],
+		})
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", private)
+	}
+
+	// The check ints let readers detect a wrong passphrase, and are random
+	// even for unencrypted keys.
+	var check [4]byte
+	if _, err := rand.Read(check[:]); err != nil {
+		return nil, err
+	}
+	checkInt := binary.BigEndian.Uint32(check[:])
+
+	privateBlock := ssh.Marshal(struct {
+		Check1  uint32
+		Check2  uint32
+		Keytype string
+		Rest    []byte `ssh:"rest"`
+	}{checkInt, checkInt, public.Type(), keyFields})
+	privateBlock = append(privateBlock, ssh.Marshal(struct{ Comment string }{comment})...)
+	// Pad to the cipher block size, which is 8 for unencrypted keys.
+	for i := 1; len(privateBlock)%8 != 0; i++ {
+		privateBlock = append(privateBlock, byte(i))
+	}
+
+	data := ssh.Marshal(struct {
+		CipherName   string
+		KdfName      string
+		KdfOpts      string
+		NumKeys      uint32
+		PubKey       []byte
+		PrivKeyBlock []byte
+	}{"none", "none", "", 1, public.Marshal(), privateBlock})
+
+	return &pem.Block{
+		Type:  "OPENSSH PRIVATE KEY",
+		Bytes: append([]byte("openssh-key-v1\x00"), data...),
+	}, nil
+}
@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+)
+
+// testResources are the resources whose stored state is checked against
+// fixtures and schema snapshots.
+var testResources = map[string]func() resource.Resource{
+	"borg_repo":         NewBorgRepoResource,
+	"generated_ssh_key": NewGeneratedSshKeyResource,
+	"ssh_key":           NewSshKeyResource,
+}
+
+// TestResourceStateFixtures checks that there's a state fixture for the
+// current and every prior schema version of each resource, and that state
+// stored by earlier releases can still be decoded or upgraded. Fixtures record
+// state as it was stored when their version was introduced, so they must not
+// be changed afterwards; attributes added since then are decoded as null.
+func TestResourceStateFixtures(t *testing.T) {
+	ctx := context.Background()
+
+	for resourceType, newResource := range testResources {
+		r := newResource()
+		t.Run(resourceType, func(t *testing.T) {
+			var schemaResp resource.SchemaResponse
+			r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+			version := schemaResp.Schema.Version
+
+			testStateFixture(t, resourceType, version, schemaResp.Schema)
+
+			upgraders := map[int64]resource.StateUpgrader{}
+			if r, ok := r.(resource.ResourceWithUpgradeState); ok {
+				upgraders = r.UpgradeState(ctx)
+			}
+			for prior := int64(0); prior < version; prior++ {
+				if _, ok := upgraders[prior]; !ok {
+					t.Errorf("missing upgrader for state v%d", prior)
+					continue
+				}
+				testUpgradeState(t, r.(resource.ResourceWithUpgradeState), resourceType, prior)
+			}
+		})
+	}
+}
+
+var updateSchemaSnapshots = flag.Bool(
+	"update-schemas",
+	false,
+	"update the schema snapshots in testdata/schema",
+)
+
+// schemaSnapshot records the Terraform type of every attribute and block of
+// a resource schema version.
+type schemaSnapshot struct {
+	Version int64             `json:"version"`
+	Types   map[string]string `json:"types"`
+}
+
+// TestResourceSchemaSnapshots checks that the type of an attribute or block
+// isn't changed without bumping the schema version, as state stored with the
+// old type could no longer be decoded. Adding attributes or bumping the
+// version requires updating the snapshots with
+// `go test ./internal/provider -run TestResourceSchemaSnapshots -update-schemas`.
+func TestResourceSchemaSnapshots(t *testing.T) {
+	ctx := context.Background()
+
+	for resourceType, newResource := range testResources {
+		r := newResource()
+		t.Run(resourceType, func(t *testing.T) {
+			var schemaResp resource.SchemaResponse
+			r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+
+			current := schemaSnapshot{
+				Version: schemaResp.Schema.Version,
+				Types:   make(map[string]string),
+			}
+			for name, attribute := range schemaResp.Schema.GetAttributes() {
+				current.Types[name] = attribute.GetType().TerraformType(ctx).String()
+			}
+			for name, block := range schemaResp.Schema.GetBlocks() {
+				current.Types[name] = block.Type().TerraformType(ctx).String()
+			}
+
+			path := filepath.Join("testdata", "schema", resourceType+".json")
+			if *updateSchemaSnapshots {
+				data, err := json.MarshalIndent(current, "", "  ")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var snapshot schemaSnapshot
+			if err := json.Unmarshal(data, &snapshot); err != nil {
+				t.Fatal(err)
+			}
+
+			if snapshot.Version == current.Version {
+				for name, snapshotType := range snapshot.Types {
+					if currentType, ok := current.Types[name]; ok && currentType != snapshotType {
+						t.Errorf("type of %s changed from %s to %s without bumping the schema version",
+							name, snapshotType, currentType)
+					}
+				}
+			}
+			if !reflect.DeepEqual(snapshot, current) {
+				t.Errorf("schema snapshot is out of date, update it with -update-schemas")
+			}
+		})
+	}
+}
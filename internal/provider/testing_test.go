@@ -0,0 +1,235 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gjabell/terraform-provider-borgbase/gql"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// testRequest is a GraphQL operation received by the stub API.
+type testRequest struct {
+	Operation string
+	Variables map[string]interface{}
+}
+
+// testApi is a stub BorgBase API which records every operation it receives.
+type testApi struct {
+	mu       sync.Mutex
+	requests []testRequest
+}
+
+// Requests returns the operations received so far.
+func (a *testApi) Requests() []testRequest {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]testRequest(nil), a.requests...)
+}
+
+// testGraphqlClient starts a stub BorgBase API and returns a client for it.
+// Each operation is answered with the data returned by handler for the
+// operation name, or with a GraphQL error if handler returns an error.
+func testGraphqlClient(
+	t *testing.T,
+	handler func(req testRequest) (interface{}, error),
+) (*gql.Client, *testApi) {
+	t.Helper()
+
+	api := &testApi{}
+	server := httptest.NewServer(http.HandlerFunc(func(
+		w http.ResponseWriter,
+		r *http.Request,
+	) {
+		var body struct {
+			Query     string `json:"query"`
+			Variables string `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Queries look like "query name(...) { ... }".
+		fields := strings.Fields(body.Query)
+		req := testRequest{Operation: strings.SplitN(fields[1], "(", 2)[0]}
+		if err := json.Unmarshal([]byte(body.Variables), &req.Variables); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		api.mu.Lock()
+		api.requests = append(api.requests, req)
+		api.mu.Unlock()
+
+		data, err := handler(req)
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"errors": []map[string]interface{}{{"message": err.Error()}},
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{req.Operation: data},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return gql.NewClient(server.URL, "token", ""), api
+}
+
+// testStateFixturePath returns the path of the state fixture for the given
+// resource type and schema version.
+func testStateFixturePath(resourceType string, version int64) string {
+	return filepath.Join("testdata", "state", fmt.Sprintf("%s_v%d.json", resourceType, version))
+}
+
+// testStateFixture decodes a state fixture using the given schema, the same
+// way the framework decodes state stored by Terraform.
+func testStateFixture(
+	t *testing.T,
+	resourceType string,
+	version int64,
+	s schema.Schema,
+) tfsdk.State {
+	t.Helper()
+
+	data, err := os.ReadFile(testStateFixturePath(resourceType, version))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := tftypes.ValueFromJSONWithOpts(
+		data,
+		s.Type().TerraformType(context.Background()),
+		tftypes.ValueFromJSONOpts{IgnoreUndefinedAttributes: true},
+	)
+	if err != nil {
+		t.Fatalf("failed to decode %s state v%d: %s", resourceType, version, err)
+	}
+	return tfsdk.State{Schema: s, Raw: raw}
+}
+
+// testUpgradeState upgrades the state fixture of the given version to the
+// current schema of the resource.
+func testUpgradeState(
+	t *testing.T,
+	r resource.ResourceWithUpgradeState,
+	resourceType string,
+	version int64,
+) tfsdk.State {
+	t.Helper()
+	ctx := context.Background()
+
+	upgrader, ok := r.UpgradeState(ctx)[version]
+	if !ok {
+		t.Fatalf("no upgrader for %s state v%d", resourceType, version)
+	}
+
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+
+	prior := testStateFixture(t, resourceType, version, *upgrader.PriorSchema)
+	resp := resource.UpgradeStateResponse{
+		State: tfsdk.State{
+			Schema: schemaResp.Schema,
+			Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+		},
+	}
+	upgrader.StateUpgrader(ctx, resource.UpgradeStateRequest{State: &prior}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("failed to upgrade %s state v%d: %s", resourceType, version, resp.Diagnostics)
+	}
+	return resp.State
+}
+
+// testKnownValue returns a known value of the given type in which every
+// attribute and element is known and not null.
+func testKnownValue(typ tftypes.Type) tftypes.Value {
+	switch {
+	case typ.Is(tftypes.String):
+		return tftypes.NewValue(typ, "test")
+	case typ.Is(tftypes.Number):
+		return tftypes.NewValue(typ, 1)
+	case typ.Is(tftypes.Bool):
+		return tftypes.NewValue(typ, true)
+	case typ.Is(tftypes.List{}):
+		return tftypes.NewValue(typ, []tftypes.Value{testKnownValue(typ.(tftypes.List).ElementType)})
+	case typ.Is(tftypes.Set{}):
+		return tftypes.NewValue(typ, []tftypes.Value{testKnownValue(typ.(tftypes.Set).ElementType)})
+	case typ.Is(tftypes.Map{}):
+		return tftypes.NewValue(typ, map[string]tftypes.Value{
+			"test": testKnownValue(typ.(tftypes.Map).ElementType),
+		})
+	case typ.Is(tftypes.Object{}):
+		attrs := make(map[string]tftypes.Value)
+		for name, attrType := range typ.(tftypes.Object).AttributeTypes {
+			attrs[name] = testKnownValue(attrType)
+		}
+		return tftypes.NewValue(typ, attrs)
+	default:
+		panic(fmt.Sprintf("unsupported type %s", typ))
+	}
+}
+
+// testDataSourceModelComplete checks that converting a resource model in
+// which every attribute is set to a data source model sets every attribute
+// of the data source.
+func testDataSourceModelComplete(
+	t *testing.T,
+	r resource.Resource,
+	d datasource.DataSource,
+	convert func(tfsdk.State) (interface{}, diag.Diagnostics),
+) {
+	t.Helper()
+	ctx := context.Background()
+
+	var resourceSchema resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &resourceSchema)
+	state := tfsdk.State{
+		Schema: resourceSchema.Schema,
+		Raw:    testKnownValue(resourceSchema.Schema.Type().TerraformType(ctx)),
+	}
+	data, diagnostics := convert(state)
+	if diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+
+	var dataSourceSchema datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &dataSourceSchema)
+	objectType := dataSourceSchema.Schema.Type().TerraformType(ctx)
+	dataSourceState := tfsdk.State{
+		Schema: dataSourceSchema.Schema,
+		Raw:    tftypes.NewValue(objectType, nil),
+	}
+	if diagnostics := dataSourceState.Set(ctx, data); diagnostics.HasError() {
+		t.Fatal(diagnostics)
+	}
+
+	for name := range objectType.(tftypes.Object).AttributeTypes {
+		var value attr.Value
+		diagnostics := dataSourceState.GetAttribute(ctx, path.Root(name), &value)
+		if diagnostics.HasError() {
+			t.Fatal(diagnostics)
+		}
+		if value.IsNull() {
+			t.Errorf("%s: not set from the resource model", name)
+		}
+	}
+}
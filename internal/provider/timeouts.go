@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// defaultTimeout is used for operations without a configured timeout.
+const defaultTimeout = 20 * time.Minute
+
+type TimeoutsModel struct {
+	Create types.String `tfsdk:"create"`
+	Delete types.String `tfsdk:"delete"`
+	Read   types.String `tfsdk:"read"`
+	Update types.String `tfsdk:"update"`
+}
+
+var timeoutsAttributes = map[string]attr.Type{
+	"create": types.StringType,
+	"delete": types.StringType,
+	"read":   types.StringType,
+	"update": types.StringType,
+}
+
+// timeoutsBlock returns the schema of the timeouts block shared by all
+// resources.
+func timeoutsBlock() schema.Block {
+	attributes := make(map[string]schema.Attribute, len(timeoutsAttributes))
+	for operation := range timeoutsAttributes {
+		attributes[operation] = schema.StringAttribute{
+			Optional: true,
+			MarkdownDescription: fmt.Sprintf("Timeout for %s operations as a "+
+				"duration such as `30s` or `5m` (defaults to %d minutes).",
+				operation, int(defaultTimeout.Minutes())),
+			Validators: []validator.String{
+				durationValidator{},
+			},
+		}
+	}
+
+	return schema.SingleNestedBlock{
+		MarkdownDescription: "Timeouts for operations on the resource.",
+		Attributes:          attributes,
+	}
+}
+
+// withTimeout returns a context which is cancelled once the timeout configured
+// for the operation has passed.
+func withTimeout(
+	ctx context.Context,
+	timeouts types.Object,
+	operation string,
+) (context.Context, context.CancelFunc, diag.Diagnostics) {
+	var diagnostics diag.Diagnostics
+
+	timeout := defaultTimeout
+	if !timeouts.IsNull() && !timeouts.IsUnknown() {
+		value, ok := timeouts.Attributes()[operation].(types.String)
+		if ok && !value.IsNull() && !value.IsUnknown() {
+			duration, err := time.ParseDuration(value.ValueString())
+			if err != nil {
+				diagnostics.AddError(
+					"Invalid timeout",
+					fmt.Sprintf("Failed to parse the %s timeout: %s", operation, err),
+				)
+				return ctx, func() {}, diagnostics
+			}
+			timeout = duration
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, diagnostics
+}
+
+// addClientError adds an error for a failed API call, explaining when it
+// failed because the operation timed out.
+func addClientError(diagnostics *diag.Diagnostics, summary string, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		diagnostics.AddError(summary, fmt.Sprintf("BorgBase didn't respond "+
+			"before the operation timed out. The timeout can be increased "+
+			"in the resource's timeouts block.\n\n%s", err))
+		return
+	}
+	diagnostics.AddError(summary, err.Error())
+}
+
+// durationValidator checks that a string is a valid duration.
+type durationValidator struct{}
+
+func (v durationValidator) Description(ctx context.Context) string {
+	return "value must be a positive duration such as \"30s\" or \"5m\""
+}
+
+func (v durationValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v durationValidator) ValidateString(
+	ctx context.Context,
+	req validator.StringRequest,
+	resp *validator.StringResponse,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	duration, err := time.ParseDuration(req.ConfigValue.ValueString())
+	if err == nil && duration <= 0 {
+		err = errors.New("duration must be positive")
+	}
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid duration",
+			fmt.Sprintf("%q is not a valid duration: %s",
+				req.ConfigValue.ValueString(), err),
+		)
+	}
+}
@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func testTimeouts(values map[string]string) types.Object {
+	attrs := make(map[string]attr.Value, len(timeoutsAttributes))
+	for operation := range timeoutsAttributes {
+		if value, ok := values[operation]; ok {
+			attrs[operation] = types.StringValue(value)
+		} else {
+			attrs[operation] = types.StringNull()
+		}
+	}
+	return types.ObjectValueMust(timeoutsAttributes, attrs)
+}
+
+func TestWithTimeout(t *testing.T) {
+	for name, tc := range map[string]struct {
+		timeouts      types.Object
+		expected      time.Duration
+		expectedError bool
+	}{
+		"null block": {
+			timeouts: types.ObjectNull(timeoutsAttributes),
+			expected: defaultTimeout,
+		},
+		"unset operation": {
+			timeouts: testTimeouts(map[string]string{"delete": "1m"}),
+			expected: defaultTimeout,
+		},
+		"configured": {
+			timeouts: testTimeouts(map[string]string{"create": "90s"}),
+			expected: 90 * time.Second,
+		},
+		"invalid": {
+			timeouts:      testTimeouts(map[string]string{"create": "soon"}),
+			expectedError: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			start := time.Now()
+			ctx, cancel, diagnostics := withTimeout(context.Background(), tc.timeouts, "create")
+			defer cancel()
+			if diagnostics.HasError() != tc.expectedError {
+				t.Fatalf("expected error %t, got %s", tc.expectedError, diagnostics)
+			}
+			if tc.expectedError {
+				return
+			}
+
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				t.Fatal("expected deadline")
+			}
+			if actual := deadline.Sub(start); actual < tc.expected || actual > tc.expected+time.Second {
+				t.Errorf("expected timeout %s, got %s", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDurationValidator(t *testing.T) {
+	for value, valid := range map[string]bool{
+		"30s":   true,
+		"1h30m": true,
+		"0s":    false,
+		"-1m":   false,
+		"5":     false,
+		"":      false,
+	} {
+		req := validator.StringRequest{
+			Path:        path.Root("timeouts").AtName("create"),
+			ConfigValue: types.StringValue(value),
+		}
+		resp := validator.StringResponse{}
+		durationValidator{}.ValidateString(context.Background(), req, &resp)
+		if resp.Diagnostics.HasError() == valid {
+			t.Errorf("%q: expected valid %t, got %s", value, valid, resp.Diagnostics)
+		}
+	}
+}
+
+func TestBorgRepoResourceRead_timeout(t *testing.T) {
+	ctx := context.Background()
+
+	// The stub API never responds until the test is over.
+	done := make(chan struct{})
+	client, _ := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		<-done
+		return nil, nil
+	})
+	t.Cleanup(func() { close(done) })
+
+	config := testBorgRepoConfig(t, map[string]tftypes.Value{
+		"id":   tftypes.NewValue(tftypes.String, "abc"),
+		"name": tftypes.NewValue(tftypes.String, "test"),
+		"timeouts": tftypes.NewValue(
+			tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+				"create": tftypes.String,
+				"delete": tftypes.String,
+				"read":   tftypes.String,
+				"update": tftypes.String,
+			}},
+			map[string]tftypes.Value{
+				"create": tftypes.NewValue(tftypes.String, nil),
+				"delete": tftypes.NewValue(tftypes.String, nil),
+				"read":   tftypes.NewValue(tftypes.String, "50ms"),
+				"update": tftypes.NewValue(tftypes.String, nil),
+			},
+		),
+	})
+	state := tfsdk.State{Schema: config.Schema, Raw: config.Raw}
+
+	r := &BorgRepoResource{client: client}
+	resp := fwresource.ReadResponse{State: state}
+	r.Read(ctx, fwresource.ReadRequest{State: state}, &resp)
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error")
+	}
+	if detail := resp.Diagnostics[0].Detail(); !strings.Contains(detail, "timed out") {
+		t.Errorf("expected timeout error, got %q", detail)
+	}
+}
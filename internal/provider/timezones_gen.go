@@ -0,0 +1,88 @@
+//go:build ignore
+
+// This program generates timezones.go from the timezone database embedded by
+// time/tzdata. Run it with go generate after updating Go.
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+func main() {
+	archive, err := zip.OpenReader(filepath.Join(runtime.GOROOT(), "lib", "time", "zoneinfo.zip"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer archive.Close()
+
+	// The database stores links as copies of the zone they link to, so
+	// names with the same data refer to the same timezone.
+	groups := make(map[string][]string)
+	var zones []string
+	for _, file := range archive.File {
+		zones = append(zones, file.Name)
+		r, err := file.Open()
+		if err != nil {
+			log.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		groups[string(data)] = append(groups[string(data)], file.Name)
+	}
+
+	links := make(map[string]string)
+	for _, names := range groups {
+		if len(names) < 2 {
+			continue
+		}
+		sort.Strings(names)
+		for _, name := range names[1:] {
+			links[strings.ToLower(name)] = strings.ToLower(names[0])
+		}
+	}
+	sort.Strings(zones)
+	keys := make([]string, 0, len(links))
+	for name := range links {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by timezones_gen.go from %s; DO NOT EDIT.\n\n", runtime.Version())
+	fmt.Fprintf(&buf, "package provider\n\n")
+	fmt.Fprintf(&buf, "// timezoneNames are the names of all timezones, which are used to suggest\n")
+	fmt.Fprintf(&buf, "// corrections for invalid ones.\n")
+	fmt.Fprintf(&buf, "var timezoneNames = []string{\n")
+	for _, name := range zones {
+		fmt.Fprintf(&buf, "\t%q,\n", name)
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+	fmt.Fprintf(&buf, "// timezoneLinks maps the lowercase names of timezones which are links to\n")
+	fmt.Fprintf(&buf, "// the lowercase name of another timezone with the same data.\n")
+	fmt.Fprintf(&buf, "var timezoneLinks = map[string]string{\n")
+	for _, name := range keys {
+		fmt.Fprintf(&buf, "\t%q: %q,\n", name, links[name])
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("timezones.go", source, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
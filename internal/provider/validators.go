@@ -0,0 +1,186 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	// Embed the IANA timezone database so that timezones can be validated
+	// on hosts without one.
+	_ "time/tzdata"
+
+	"github.com/gjabell/terraform-provider-borgbase/gql"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// knownBorgVersions are the borg versions offered by BorgBase at the time of
+// release. The list is refreshed from the API when planning a version which
+// isn't in it.
+var knownBorgVersions = []string{"LATEST", "1.1", "1.2", "1.4"}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, minInt(cur[j-1]+1, prev[j-1]+cost))
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// suggest returns the candidate closest to value (ignoring case), or "" if
+// none is close enough to be a likely typo.
+func suggest(value string, candidates []string) string {
+	best, bestDistance := "", len(value)/3+2
+	for _, candidate := range candidates {
+		distance := levenshtein(strings.ToLower(value), strings.ToLower(candidate))
+		if distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// didYouMean formats a suggestion for an error message.
+func didYouMean(suggestion string) string {
+	if suggestion == "" {
+		return ""
+	}
+	return fmt.Sprintf(" Did you mean %q?", suggestion)
+}
+
+// timezoneValidator checks that a string is a timezone in the IANA database.
+type timezoneValidator struct{}
+
+func (v timezoneValidator) Description(ctx context.Context) string {
+	return "value must be an IANA timezone such as \"Europe/Berlin\" or \"UTC\""
+}
+
+func (v timezoneValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v timezoneValidator) ValidateString(
+	ctx context.Context,
+	req validator.StringRequest,
+	resp *validator.StringResponse,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	timezone := req.ConfigValue.ValueString()
+	if timezone != "" && timezone != "Local" {
+		if _, err := time.LoadLocation(timezone); err == nil {
+			return
+		}
+	}
+
+	resp.Diagnostics.AddAttributeError(
+		req.Path,
+		"Invalid timezone",
+		fmt.Sprintf("%q is not a timezone in the IANA timezone database.%s",
+			timezone, didYouMean(suggest(timezone, timezoneNames))),
+	)
+}
+
+// isKnownBorgVersion returns whether version is one of the given versions,
+// ignoring case.
+func isKnownBorgVersion(version string, versions []string) bool {
+	for _, known := range versions {
+		if strings.EqualFold(version, known) {
+			return true
+		}
+	}
+	return false
+}
+
+// borgVersionPattern matches version numbers such as "1.2" or "2.0b1".
+var borgVersionPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*[0-9A-Za-z.+-]*$`)
+
+// borgVersionValidator rejects borg versions which are neither "LATEST" nor a
+// version number. Version numbers may have been added since release, so they
+// are checked against the API during planning instead.
+type borgVersionValidator struct{}
+
+func (v borgVersionValidator) Description(ctx context.Context) string {
+	return fmt.Sprintf("value must be a borg version offered by BorgBase, e.g. %s",
+		strings.Join(knownBorgVersions, ", "))
+}
+
+func (v borgVersionValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v borgVersionValidator) ValidateString(
+	ctx context.Context,
+	req validator.StringRequest,
+	resp *validator.StringResponse,
+) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	version := req.ConfigValue.ValueString()
+	if isKnownBorgVersion(version, knownBorgVersions) || borgVersionPattern.MatchString(version) {
+		return
+	}
+	resp.Diagnostics.AddAttributeError(
+		req.Path,
+		"Invalid borg version",
+		fmt.Sprintf("%q is not a known borg version.%s",
+			version, didYouMean(suggest(version, knownBorgVersions))),
+	)
+}
+
+// borgVersionCache caches the borg versions offered by the API for the client
+// of a configured provider.
+type borgVersionCache struct {
+	sync.Mutex
+	versions []string
+	err      error
+}
+
+// list returns the borg versions offered by the API, fetching them at most
+// once. A failed lookup is cached as well, so that it's reported for every
+// repo without being retried for each of them, unless it failed because the
+// operation was cancelled or timed out.
+func (c *borgVersionCache) list(ctx context.Context, client *gql.Client) ([]string, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.versions == nil && c.err == nil {
+		var versions []string
+		err := client.Query(ctx, "borgVersions", &versions, gql.Arguments{})
+		if err != nil && ctx.Err() != nil {
+			return nil, err
+		}
+		if err == nil {
+			versions = append([]string{"LATEST"}, versions...)
+		}
+		c.versions, c.err = versions, err
+	}
+	return c.versions, c.err
+}
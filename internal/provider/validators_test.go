@@ -0,0 +1,192 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestLevenshtein(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"1.2", "1.2", 0},
+		{"Europe/Berln", "Europe/Berlin", 1},
+	} {
+		if actual := levenshtein(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("%q, %q: expected %d, got %d", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
+
+func TestTimezoneValidator(t *testing.T) {
+	ctx := context.Background()
+
+	for value, valid := range map[string]bool{
+		"UTC":              true,
+		"Europe/Berlin":    true,
+		"America/New_York": true,
+		"Europe/Berln":     false,
+		"Mars/Olympus":     false,
+		"Local":            false,
+		"":                 false,
+	} {
+		req := validator.StringRequest{
+			Path:        path.Root("hour_timezone"),
+			ConfigValue: types.StringValue(value),
+		}
+		resp := validator.StringResponse{}
+		timezoneValidator{}.ValidateString(ctx, req, &resp)
+		if resp.Diagnostics.HasError() == valid {
+			t.Errorf("%q: expected valid %t, got %s", value, valid, resp.Diagnostics)
+		}
+	}
+
+	for _, value := range []types.String{types.StringNull(), types.StringUnknown()} {
+		req := validator.StringRequest{Path: path.Root("hour_timezone"), ConfigValue: value}
+		resp := validator.StringResponse{}
+		timezoneValidator{}.ValidateString(ctx, req, &resp)
+		if resp.Diagnostics.HasError() {
+			t.Errorf("%s: unexpected error %s", value, resp.Diagnostics)
+		}
+	}
+}
+
+func TestTimezoneValidator_suggestion(t *testing.T) {
+	// Suggestions don't depend on a timezone database on the host.
+	t.Setenv("ZONEINFO", t.TempDir())
+
+	req := validator.StringRequest{
+		Path:        path.Root("hour_timezone"),
+		ConfigValue: types.StringValue("Europe/Berln"),
+	}
+	resp := validator.StringResponse{}
+	timezoneValidator{}.ValidateString(context.Background(), req, &resp)
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error")
+	}
+	if detail := resp.Diagnostics[0].Detail(); !strings.Contains(detail, `"Europe/Berlin"`) {
+		t.Errorf("expected suggestion Europe/Berlin, got %q", detail)
+	}
+}
+
+func TestBorgVersionValidator(t *testing.T) {
+	ctx := context.Background()
+
+	for value, tc := range map[string]struct {
+		valid      bool
+		suggestion string
+	}{
+		"LATEST":  {valid: true},
+		"latest":  {valid: true},
+		"1.2":     {valid: true},
+		"2.0b1":   {valid: true},
+		"LATES":   {suggestion: "LATEST"},
+		"lastest": {suggestion: "LATEST"},
+		"v1.2":    {},
+		"":        {},
+	} {
+		req := validator.StringRequest{
+			Path:        path.Root("borg_version"),
+			ConfigValue: types.StringValue(value),
+		}
+		resp := validator.StringResponse{}
+		borgVersionValidator{}.ValidateString(ctx, req, &resp)
+		if resp.Diagnostics.HasError() == tc.valid {
+			t.Errorf("%q: expected valid %t, got %s", value, tc.valid, resp.Diagnostics)
+			continue
+		}
+		if tc.suggestion == "" {
+			continue
+		}
+		if detail := resp.Diagnostics[0].Detail(); !strings.Contains(detail, tc.suggestion) {
+			t.Errorf("%q: expected suggestion %q, got %q", value, tc.suggestion, detail)
+		}
+	}
+}
+
+func TestBorgRepoResourceModifyPlan_borgVersion(t *testing.T) {
+	ctx := context.Background()
+
+	for name, tc := range map[string]struct {
+		version       string
+		expectedError bool
+		expectedCalls int
+	}{
+		"known":                 {version: "1.2"},
+		"offered by api":        {version: "2.0", expectedCalls: 1},
+		"not offered":           {version: "3.0", expectedError: true, expectedCalls: 1},
+		"offered, ignores case": {version: "2.0B1", expectedCalls: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			client, api := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+				return []string{"1.1", "1.2", "1.4", "2.0", "2.0b1"}, nil
+			})
+
+			values := map[string]tftypes.Value{
+				"borg_version": tftypes.NewValue(tftypes.String, tc.version),
+			}
+			config := testBorgRepoConfig(t, values)
+			plan := tfsdk.Plan{Schema: config.Schema, Raw: config.Raw}
+
+			r := &BorgRepoResource{client: client, borgVersions: &borgVersionCache{}}
+			req := fwresource.ModifyPlanRequest{Config: config, Plan: plan}
+			resp := fwresource.ModifyPlanResponse{Plan: plan}
+			r.ModifyPlan(ctx, req, &resp)
+			if resp.Diagnostics.HasError() != tc.expectedError {
+				t.Errorf("expected error %t, got %s", tc.expectedError, resp.Diagnostics)
+			}
+			if calls := len(api.Requests()); calls != tc.expectedCalls {
+				t.Errorf("expected %d API calls, got %d", tc.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestBorgVersionCacheList(t *testing.T) {
+	failing := true
+	client, api := testGraphqlClient(t, func(req testRequest) (interface{}, error) {
+		if failing {
+			return nil, fmt.Errorf("unavailable")
+		}
+		return []string{"1.2"}, nil
+	})
+
+	// A lookup which failed because its context ended isn't cached.
+	cache := &borgVersionCache{}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := cache.list(cancelled, client); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Other failures are cached, so they're only looked up once.
+	for i := 0; i < 2; i++ {
+		if _, err := cache.list(context.Background(), client); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if calls := len(api.Requests()); calls != 1 {
+		t.Errorf("expected 1 API call, got %d", calls)
+	}
+
+	// Each provider client has its own cache.
+	failing = false
+	versions, err := (&borgVersionCache{}).list(context.Background(), client)
+	if err != nil || !reflect.DeepEqual(versions, []string{"LATEST", "1.2"}) {
+		t.Errorf("expected [LATEST 1.2], got %v, %v", versions, err)
+	}
+}
@@ -3,7 +3,10 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 
 	"github.com/gjabell/terraform-provider-borgbase/internal/provider"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
@@ -22,6 +25,14 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := export(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "export: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var debug bool
 
 	flag.BoolVar(
@@ -43,3 +54,48 @@ func main() {
 		log.Fatal(err.Error())
 	}
 }
+
+// export generates Terraform configuration for the resources in a BorgBase
+// account, so that they can be adopted with import blocks.
+func export(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s export [-output file]\n\n"+
+			"Writes Terraform configuration and import blocks for every SSH key "+
+			"and Borg repo in the\nBorgBase account of the token in the "+
+			"BORGBASE_API_TOKEN env var.\n\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	output := flags.String(
+		"output",
+		"",
+		"file to write the configuration to instead of stdout",
+	)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *output == "" {
+		return provider.Export(context.Background(), os.Stdout, version)
+	}
+
+	// Write to a temporary file which only replaces the output file once the
+	// export succeeded, so that a failed export leaves it untouched.
+	file, err := os.CreateTemp(filepath.Dir(*output), filepath.Base(*output)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	err = provider.Export(context.Background(), file, version)
+	if err == nil {
+		err = file.Chmod(0o644)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), *output)
+}